@@ -0,0 +1,11 @@
+// Package config holds the sample configuration file shipped with
+// GopherTube.
+package config
+
+import _ "embed"
+
+// Default is the commented configuration file written by `config init`.
+// Every value in it is the built-in default.
+//
+//go:embed gophertube.toml
+var Default []byte
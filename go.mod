@@ -5,12 +5,10 @@ go 1.23.2
 toolchain go1.24.5
 
 require (
+	github.com/BurntSushi/toml v1.5.0
 	github.com/chzyer/readline v1.5.1
-	github.com/urfave/cli-altsrc/v3 v3.0.1
 	github.com/urfave/cli/v3 v3.3.8
+	golang.org/x/image v0.24.0
 )
 
-require (
-	github.com/BurntSushi/toml v1.5.0 // indirect
-	golang.org/x/sys v0.34.0 // indirect
-)
+require golang.org/x/sys v0.34.0 // indirect
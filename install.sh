@@ -131,7 +131,6 @@ main() {
   ensure_cmd git   "$pm" git git git git git git git
   ensure_cmd mpv   "$pm" mpv mpv mpv mpv mpv mpv mpv
   ensure_cmd fzf   "$pm" fzf fzf fzf fzf fzf fzf fzf
-  ensure_cmd chafa "$pm" chafa chafa chafa chafa chafa chafa chafa
   ensure_yt_dlp "$pm"
 
   # Determine latest version tag from GitHub
@@ -187,7 +186,7 @@ main() {
   fi
 
   # User config bootstrap (do not overwrite)
-  cfg_dir="$HOME/.config/gophertube"
+  cfg_dir="${XDG_CONFIG_HOME:-$HOME/.config}/gophertube"
   cfg_file="$cfg_dir/gophertube.toml"
   mkdir -p "$cfg_dir"
   if [ ! -f "$cfg_file" ] && [ -f "$TMPDIR/GopherTube/config/gophertube.toml" ]; then
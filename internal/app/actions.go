@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"gophertube/internal/services"
+	"gophertube/internal/types"
+)
+
+// runAction does what action stands for with video, straight from the
+// result list.
+func (s *session) runAction(ctx context.Context, action keyAction, video types.Video) {
+	switch action {
+	case keyWatch, keyListen, keyDownload:
+		if s.offline {
+			s.showUnavailable()
+			return
+		}
+		switch action {
+		case keyWatch:
+			s.watch(ctx, video)
+		case keyListen:
+			s.listen(ctx, video)
+		default:
+			s.download(ctx, video)
+		}
+	case keyQueue:
+		s.enqueue(video)
+	case keyDetails:
+		s.showDetails(video)
+	case keyOpen:
+		if err := openInBrowser(video.URL); err != nil {
+			s.ui.ShowMessage(colorError + "Could not open a browser: " + err.Error() + colorReset)
+			s.ui.Pause("Press any key to return...")
+		}
+	case keyCopyURL:
+		s.ui.CopyToClipboard(video.URL)
+		s.ui.ShowMessage(colorSuccess + "Copied " + video.URL + colorReset)
+		time.Sleep(600 * time.Millisecond)
+	case keyChannel:
+		s.channelMode(ctx, video)
+	default:
+		s.videoAction(ctx, video)
+	}
+}
+
+func (s *session) showUnavailable() {
+	s.ui.ShowMessage(colorNotice + "Streaming and downloading need a connection to YouTube." + colorReset)
+	s.ui.Pause("Press any key to return...")
+}
+
+// enqueue adds video to the playlist of the background player, or to the
+// queue played from the main menu when nothing plays.
+func (s *session) enqueue(video types.Video) {
+	if p := s.activePlayer(); p != nil {
+		if err := p.Append(video.URL); err == nil {
+			s.playing = append(s.playing, video)
+			services.AppendHistory(video)
+			s.ui.ShowMessage(fmt.Sprintf("%sAdded to the player: %s (%d in playlist)%s", colorSuccess, video.Title, len(s.playing), colorReset))
+			time.Sleep(600 * time.Millisecond)
+			return
+		}
+	}
+	for _, v := range s.queue {
+		if v.URL == video.URL {
+			s.ui.ShowMessage(colorNotice + "Already queued: " + video.Title + colorReset)
+			time.Sleep(600 * time.Millisecond)
+			return
+		}
+	}
+	s.queue = append(s.queue, video)
+	services.SaveQueue(s.queue)
+	s.ui.ShowMessage(fmt.Sprintf("%sQueued: %s (%d in queue)%s", colorSuccess, video.Title, len(s.queue), colorReset))
+	time.Sleep(600 * time.Millisecond)
+}
+
+// playQueue plays the queued videos as audio, one after the other, and
+// empties the queue. With audio already playing they are added to its
+// playlist instead.
+func (s *session) playQueue(ctx context.Context) {
+	queue := s.queue
+	s.queue = nil
+	services.SaveQueue(nil)
+	p := s.activePlayer()
+	if p == nil {
+		s.playAudio(ctx, queue)
+		return
+	}
+	for _, v := range queue {
+		if p.Append(v.URL) == nil {
+			s.playing = append(s.playing, v)
+			services.AppendHistory(v)
+		}
+	}
+	s.nowPlaying(ctx)
+}
+
+// showDetails prints everything known about video.
+func (s *session) showDetails(video types.Video) {
+	s.ui.ShowMessage(colorInfo + video.Title + colorReset)
+	s.showVideoInfo(video)
+	if video.Views != "" {
+		s.ui.ShowMessage(fmt.Sprintf("%sViews: %s%s", colorText, video.Views, colorReset))
+	}
+	s.ui.ShowMessage(fmt.Sprintf("%sURL: %s%s", colorText, video.URL, colorReset))
+	if video.Description != "" {
+		s.ui.ShowMessage("")
+		s.ui.ShowMessage(video.Description)
+	}
+	s.ui.ShowMessage("")
+	s.ui.Pause("Press any key to return...")
+}
+
+// channelMode lists the latest uploads of the channel that published
+// video. Entries without a channel id, like old history, fall back to a
+// search for the channel name.
+func (s *session) channelMode(ctx context.Context, video types.Video) {
+	if video.ChannelID == "" {
+		if video.Author == "" || s.offline {
+			s.ui.ShowMessage(colorNotice + "No channel known for this video." + colorReset)
+			time.Sleep(600 * time.Millisecond)
+			return
+		}
+		view := s.view
+		s.searchAndBrowse(ctx, video.Author)
+		s.view = view
+		return
+	}
+	if s.offline {
+		s.showUnavailable()
+		return
+	}
+
+	s.ui.ShowMessage(fmt.Sprintf("%sLoading videos from %s...%s", colorAccent, video.Author, colorReset))
+	videos, err := services.ChannelVideos(ctx, video, s.cmd.Int(FlagSearchLimit))
+	if ctx.Err() != nil {
+		return
+	}
+	videos = s.blocklist.filter(videos)
+	if err == nil && len(videos) == 0 {
+		err = services.ErrNoResults
+	}
+	if err != nil {
+		msg, hint := searchErrorMessage(err)
+		s.ui.ShowMessage(colorError + msg + colorReset)
+		if hint != "" {
+			s.ui.ShowMessage(colorText + hint + colorReset)
+		}
+		s.ui.Pause("Press any key to return...")
+		return
+	}
+
+	// The channel list has its own sort and filter
+	view := s.view
+	s.browse(ctx, videos, "")
+	s.view = view
+}
+
+// openInBrowser hands url to the desktop's default browser without
+// waiting for it.
+func openInBrowser(url string) error {
+	name := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		name = "open"
+	}
+	cmd := exec.Command(name, url)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
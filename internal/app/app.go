@@ -4,12 +4,13 @@ import (
 	"context"
 	_ "embed"
 	"fmt"
+	"gophertube/internal/services"
 	"os"
 	"os/exec"
 	"os/signal"
-	"strings"
 	"syscall"
 
+	"github.com/chzyer/readline"
 	"github.com/urfave/cli/v3"
 )
 
@@ -18,6 +19,16 @@ var version = "dev"
 //go:embed description.txt
 var Desc string
 
+// FormatError renders err for the terminal in the error color of the
+// theme, or plainly when NO_COLOR is set. Errors from before the config is
+// read get the colors of the default theme.
+func FormatError(err error) string {
+	if os.Getenv("NO_COLOR") != "" {
+		return err.Error()
+	}
+	return colorError + err.Error() + colorReset
+}
+
 func New() cli.Command {
 	return cli.Command{
 		Name: "GopherTube",
@@ -28,54 +39,167 @@ func New() cli.Command {
 		Description: Desc,
 		Flags:       Flags(),
 		Version:     version,
+		Before:      Before,
 		Action:      Action,
+		Commands: []*cli.Command{
+			cacheCommand(),
+			configCommand(),
+			previewThumbCommand(),
+		},
 	}
 }
 
+// Before applies the parsed configuration to the services package. It runs
+// ahead of the main action as well as every subcommand.
+func Before(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	if cmd.Args().First() == "config" {
+		// Fixing a broken config file must not depend on it being valid
+		return ctx, nil
+	}
+	fc, err := loadFileConfig(cmd.String(FlagConfig), cmd.String(FlagProfile))
+	if err != nil {
+		return ctx, err
+	}
+	ctx = withConfig(ctx, fc)
+
+	t, err := fc.Theme.resolve(os.Getenv("NO_COLOR") != "")
+	if err != nil {
+		return ctx, err
+	}
+	applyTheme(t)
+
+	if err := fc.Ytdlp.validate(); err != nil {
+		return ctx, err
+	}
+	if err := fc.Ytdlp.validQuality(cmd.String(FlagQuality)); err != nil {
+		return ctx, err
+	}
+	if err := fc.Thumbnails.validate(); err != nil {
+		return ctx, err
+	}
+	if err := fc.Audio.validate(); err != nil {
+		return ctx, err
+	}
+	services.SetYtDlpPath(fc.Ytdlp.withDefaults().Path)
+
+	locale, err := services.ParseLocale(cmd.String(FlagLanguage), cmd.String(FlagRegion))
+	if err != nil {
+		return ctx, err
+	}
+	services.SetLocale(locale)
+
+	nc, err := fc.Network.resolve()
+	if err != nil {
+		return ctx, err
+	}
+	if err := services.SetNetwork(nc); err != nil {
+		return ctx, err
+	}
+	if err := services.SetCookies(services.CookieConfig{
+		File:    expandPath(cmd.String(FlagCookies)),
+		Browser: cmd.String(FlagCookiesBrowser),
+	}); err != nil {
+		return ctx, err
+	}
+
+	if cmd.Bool(FlagDebug) {
+		services.SetDebugDir(services.DefaultDebugDir())
+	}
+
+	services.SetThumbCache(services.NewThumbCache(
+		services.DefaultThumbDir(),
+		int64(cmd.Int(FlagThumbCacheSize))<<20,
+	))
+	if cmd.Bool(FlagNoCache) {
+		services.SetSearchCache(nil)
+	} else {
+		services.SetSearchCache(services.NewSearchCache(
+			services.DefaultSearchCacheDir(),
+			cmd.Duration(FlagSearchCacheTTL),
+		))
+	}
+	return ctx, nil
+}
+
 // Action is the equivalent of the main except that all flags/configs
 // have already been parsed and sanitized.
 func Action(ctx context.Context, cmd *cli.Command) error {
-	// Handle Ctrl+C gracefully
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigChan
-		fmt.Println()
-		fmt.Println("\033[1;33mExiting...\033[0m")
-		os.Exit(0)
-	}()
+	// Ctrl+C and SIGTERM cancel ctx, which stops running searches and
+	// child processes and lets every mode return to this loop.
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	// Whatever state a cancelled prompt or child left the terminal in,
+	// leave it the way it was found.
+	if state, err := readline.GetState(int(os.Stdin.Fd())); err == nil {
+		defer readline.Restore(int(os.Stdin.Fd()), state)
+	}
+
+	// Check if fzf is installed
+	config := configFrom(ctx)
+	fzf := config.Fzf.withDefaults()
+	path, err := exec.LookPath(fzf.Path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "fzf not found. Please install fzf and ensure it is on PATH, or set path in [fzf].")
+		return nil
+	}
+	s, err := newSession(cmd, newFzfUI(ctx, path, fzf.Args), config)
+	if err != nil {
+		return err
+	}
+	// Background audio ends with the app
+	defer s.stopPlayer()
+	s.checkOnline(ctx)
+	s.run(ctx)
+	if ctx.Err() == nil {
+		return nil // left with Esc
+	}
+	fmt.Println()
+	fmt.Println(colorNotice + "Exiting..." + colorReset)
+	return nil
+}
 
+// run shows the main menu and the mode picked from it until the user
+// leaves with Esc or ctx is cancelled.
+func (s *session) run(ctx context.Context) {
 	for {
-		mainMenu := []string{"Search YouTube", "Search Downloads"}
+		s.recheckOnline(ctx)
+		mainMenu, header := s.mainMenu()
 
-		// Check if fzf is installed
-		path, err := exec.LookPath("fzf")
-		if err != nil {
-			fmt.Fprintln(os.Stderr, "fzf not found. Please install fzf and ensure it is on PATH.")
-			return nil
+		liveHeader := s.whilePlaying(func() string {
+			_, header := s.mainMenu()
+			return header
+		})
+		c, err := s.ui.ChooseOne(PickOptions{Prompt: "Select mode: ", Header: header, LiveHeader: liveHeader, Ansi: true}, mainMenu)
+		if ctx.Err() != nil {
+			return
 		}
-		var choice string
-		action := exec.CommandContext(ctx, path, "--prompt=Select mode: ")
-		action.Stdin = strings.NewReader(strings.Join(mainMenu, "\n"))
-		out, err := action.Output()
-		if err != nil {
+		if err != nil || c.Index < 0 {
 			// ESC/cancel or fzf error: exit app
-			return nil
-		}
-		choice = strings.TrimSpace(string(out))
-		if choice == "" {
-			// Empty selection (e.g., ESC): exit app
-			return nil
+			return
 		}
 
-		switch choice {
-		case "Search YouTube":
-			gophertubeYouTubeMode(cmd)
-		case "Search Downloads":
-			gophertubeDownloadsMode(cmd)
+		switch mainMenu[c.Index] {
+		case menuPlayer:
+			s.nowPlaying(ctx)
+		case menuPlayQueue:
+			s.playQueue(ctx)
+		case menuSearchYouTube:
+			s.youTubeMode(ctx)
+		case menuSearchDownloads:
+			s.downloadsMode(ctx)
+		case menuHistory:
+			s.historyMode(ctx)
+		case menuCachedSearches:
+			s.cachedSearchesMode(ctx)
+		case menuYouTubeOffline:
+			s.ui.ShowMessage(colorNotice + "YouTube cannot be reached. Previous searches are under '" + menuCachedSearches + "'." + colorReset)
+			s.ui.Pause("Press any key to return...")
 		default:
 			// Unknown/empty selection: continue loop and ask again
 			continue
 		}
+		if ctx.Err() != nil {
+			return
+		}
 	}
 }
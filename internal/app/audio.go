@@ -0,0 +1,61 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// audioConfig is the [audio] table of the config file: how audio downloads
+// are extracted and tagged.
+type audioConfig struct {
+	Format         string `toml:"format"`          // mp3, m4a, opus or flac, empty keeps the downloaded codec
+	Bitrate        string `toml:"bitrate"`         // e.g. "192k", or VBR quality from 0 (best) to 10
+	EmbedMetadata  bool   `toml:"embed_metadata"`  // title, channel, date and description as tags
+	EmbedThumbnail bool   `toml:"embed_thumbnail"` // thumbnail as cover art
+	ParseArtist    bool   `toml:"parse_artist"`    // "Artist - Title" into artist and title tags
+}
+
+// audioFormats are the values of [audio] format, named like the extension
+// of the files yt-dlp writes.
+var audioFormats = []string{"mp3", "m4a", "opus", "flac"}
+
+// audioBitrateRegex matches what yt-dlp --audio-quality takes.
+var audioBitrateRegex = regexp.MustCompile(`^(10|[0-9]|[1-9][0-9]*[kK])$`)
+
+func (c audioConfig) validate() error {
+	if c.Format != "" && !slices.Contains(audioFormats, strings.ToLower(c.Format)) {
+		return fmt.Errorf("audio: unknown format %q (%s)", c.Format, strings.Join(audioFormats, ", "))
+	}
+	if c.Bitrate != "" && !audioBitrateRegex.MatchString(c.Bitrate) {
+		return fmt.Errorf("audio: invalid bitrate %q, expected e.g. 192k or a VBR quality from 0 to 10", c.Bitrate)
+	}
+	return nil
+}
+
+// args are the yt-dlp arguments extracting the audio of a download.
+func (c audioConfig) args() []string {
+	args := []string{"-x"}
+	if c.Format != "" {
+		args = append(args, "--audio-format", strings.ToLower(c.Format))
+	}
+	if c.Bitrate != "" {
+		args = append(args, "--audio-quality", c.Bitrate)
+	}
+	if c.ParseArtist {
+		// The channel is the artist unless YouTube knows better or the
+		// title names one
+		args = append(args,
+			"--parse-metadata", "%(artist,uploader)s:%(artist)s",
+			"--parse-metadata", "title:%(artist)s - %(title)s",
+		)
+	}
+	if c.EmbedMetadata || c.ParseArtist {
+		args = append(args, "--embed-metadata")
+	}
+	if c.EmbedThumbnail {
+		args = append(args, "--embed-thumbnail")
+	}
+	return args
+}
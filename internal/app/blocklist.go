@@ -0,0 +1,103 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gophertube/internal/types"
+)
+
+// blocklistConfig is the [blocklist] table of the config file.
+type blocklistConfig struct {
+	Channels      []string `toml:"channels"`       // channel names or IDs (UC...)
+	TitlePatterns []string `toml:"title_patterns"` // regular expressions
+	MinDuration   string   `toml:"min_duration"`   // e.g. "60s" to skip shorts
+}
+
+// blocklist is the compiled form of blocklistConfig.
+type blocklist struct {
+	channels    map[string]bool
+	titles      []*regexp.Regexp
+	minDuration time.Duration
+}
+
+func newBlocklist(c blocklistConfig) (*blocklist, error) {
+	b := &blocklist{channels: make(map[string]bool)}
+	for _, ch := range c.Channels {
+		b.channels[strings.ToLower(strings.TrimSpace(ch))] = true
+	}
+	for _, p := range c.TitlePatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("blocklist: invalid title pattern %q: %w", p, err)
+		}
+		b.titles = append(b.titles, re)
+	}
+	if c.MinDuration != "" {
+		d, err := time.ParseDuration(c.MinDuration)
+		if err != nil {
+			return nil, fmt.Errorf("blocklist: invalid min_duration %q: %w", c.MinDuration, err)
+		}
+		b.minDuration = d
+	}
+	return b, nil
+}
+
+// blocked reports whether v matches any rule.
+func (b *blocklist) blocked(v types.Video) bool {
+	if b.blockedChannel(v) {
+		return true
+	}
+	for _, re := range b.titles {
+		if re.MatchString(v.Title) {
+			return true
+		}
+	}
+	// Live streams have no length and are never cut by the duration rule
+	return b.minDuration > 0 && v.Length > 0 && v.Length < b.minDuration
+}
+
+// blockedChannel reports whether the channel of v is on the list, by name
+// or by ID.
+func (b *blocklist) blockedChannel(v types.Video) bool {
+	return b.channels[strings.ToLower(v.Author)] || (v.ChannelID != "" && b.channels[strings.ToLower(v.ChannelID)])
+}
+
+// filter returns the videos that are not blocked.
+func (b *blocklist) filter(videos []types.Video) []types.Video {
+	if b == nil {
+		return videos
+	}
+	kept := make([]types.Video, 0, len(videos))
+	for _, v := range videos {
+		if !b.blocked(v) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// blockChannel adds the channel of v to the blocklist in memory and in the
+// config file. The ID is stored when known, names can change.
+func (s *session) blockChannel(v types.Video) error {
+	entry := v.ChannelID
+	if entry == "" {
+		entry = v.Author
+	}
+	if entry == "" {
+		return fmt.Errorf("video has no channel")
+	}
+	if s.blocklist.blockedChannel(v) {
+		return nil
+	}
+	s.config.Blocklist.Channels = append(s.config.Blocklist.Channels, entry)
+	s.blocklist.channels[strings.ToLower(entry)] = true
+	// The list goes where it came from, the profile or the base settings
+	path, table := s.cmd.String(FlagConfig), "blocklist"
+	if p := s.cmd.String(FlagProfile); profileDefines(path, p, "blocklist", "channels") {
+		table = "profile." + tomlString(p) + ".blocklist"
+	}
+	return setConfigValue(path, table, "channels", tomlStringArray(s.config.Blocklist.Channels))
+}
@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"gophertube/internal/services"
+
+	"github.com/urfave/cli/v3"
+)
+
+// cacheCommand groups the maintenance actions for on-disk caches.
+func cacheCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "cache",
+		Usage: "Inspect or clear cached data",
+		Commands: []*cli.Command{
+			{
+				Name:   "stats",
+				Usage:  "Show cache location and size",
+				Action: cacheStatsAction,
+			},
+			{
+				Name:   "clear",
+				Usage:  "Delete all cached data",
+				Action: cacheClearAction,
+			},
+		},
+	}
+}
+
+func cacheStatsAction(ctx context.Context, cmd *cli.Command) error {
+	thumbs := services.Thumbs()
+	st, err := thumbs.Stats()
+	if err != nil {
+		return err
+	}
+	limit := "unlimited"
+	if thumbs.MaxBytes > 0 {
+		limit = formatBytes(thumbs.MaxBytes)
+	}
+	fmt.Printf("Thumbnails: %s\n", thumbs.Dir)
+	fmt.Printf("  %d files, %s of %s\n", st.Files, formatBytes(st.Bytes), limit)
+
+	searches := services.NewSearchCache(services.DefaultSearchCacheDir(), cmd.Duration(FlagSearchCacheTTL))
+	st, err = searches.Stats()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Search results: %s\n", searches.Dir)
+	fmt.Printf("  %d queries, %s, kept fresh for %s\n", st.Files, formatBytes(st.Bytes), searches.TTL)
+	return nil
+}
+
+func cacheClearAction(ctx context.Context, cmd *cli.Command) error {
+	if err := services.Thumbs().Clear(); err != nil {
+		return err
+	}
+	if err := services.NewSearchCache(services.DefaultSearchCacheDir(), 0).Clear(); err != nil {
+		return err
+	}
+	fmt.Println("Cache cleared.")
+	return nil
+}
+
+// formatBytes renders n using binary units.
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
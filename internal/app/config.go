@@ -0,0 +1,523 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v3"
+)
+
+// topLevelConfig lists the plain settings of the config file with the flag
+// each one feeds. The flags read them through configSource, the struct is
+// what `config validate` and `config show` go by.
+type topLevelConfig struct {
+	DownloadsPath      string `toml:"downloads_path" flag:"downloads-path"`
+	SearchLimit        int    `toml:"search_limit" flag:"search-limit"`
+	Quality            string `toml:"quality" flag:"quality"`
+	ThumbCacheSize     int    `toml:"thumb_cache_size" flag:"thumb-cache-size"`
+	SearchCacheTTL     string `toml:"search_cache_ttl" flag:"search-cache-ttl"`
+	Region             string `toml:"region" flag:"region"`
+	Language           string `toml:"language" flag:"language"`
+	Cookies            string `toml:"cookies" flag:"cookies"`
+	CookiesFromBrowser string `toml:"cookies_from_browser" flag:"cookies-from-browser"`
+}
+
+// configLocation is the config file and profile picked by --config and
+// --profile. The flags fill it in before any value is looked up.
+type configLocation struct {
+	path, profile string
+
+	loadedFor [2]string // path and profile of tree
+	tree      map[string]any
+}
+
+// lookup returns the value of the top-level key with the profile applied.
+// The file is read once for all flags.
+func (l *configLocation) lookup(key string) (any, bool) {
+	if l.tree == nil || l.loadedFor != [2]string{l.path, l.profile} {
+		tree, err := loadConfigTree(l.path, l.profile)
+		if err != nil {
+			tree = map[string]any{} // Before reports the error
+		}
+		l.tree, l.loadedFor = tree, [2]string{l.path, l.profile}
+	}
+	v, ok := l.tree[key]
+	return v, ok
+}
+
+// configValue is a cli.ValueSource reading a top-level key of the config
+// file.
+type configValue struct {
+	key string
+	loc *configLocation
+}
+
+func (v configValue) Lookup() (string, bool) {
+	val, ok := v.loc.lookup(v.key)
+	if _, table := val.(map[string]any); !ok || table {
+		return "", false
+	}
+	return fmt.Sprint(val), true
+}
+
+func (v configValue) String() string {
+	return fmt.Sprintf("key %q in the config file", v.key)
+}
+
+func (v configValue) GoString() string {
+	return fmt.Sprintf("configValue{key:%q}", v.key)
+}
+
+// configSource reads the flag value from key of the config file at loc.
+func configSource(key string, loc *configLocation) cli.ValueSourceChain {
+	return cli.NewValueSourceChain(configValue{key: key, loc: loc})
+}
+
+// fileConfig holds the tables of the config file. Plain top-level settings
+// are read through the flags in Flags(), tables that do not map onto a
+// single flag are decoded here.
+type fileConfig struct {
+	Blocklist  blocklistConfig  `toml:"blocklist"`
+	Network    networkConfig    `toml:"network"`
+	Keys       keysConfig       `toml:"keys"`
+	Theme      themeConfig      `toml:"theme"`
+	Player     playerConfig     `toml:"player"`
+	Fzf        fzfConfig        `toml:"fzf"`
+	Ytdlp      ytdlpConfig      `toml:"ytdlp"`
+	Thumbnails thumbnailsConfig `toml:"thumbnails"`
+	Audio      audioConfig      `toml:"audio"`
+}
+
+type configKey struct{}
+
+// loadFileConfig decodes the config file at path with the named profile
+// applied. A missing file is not an error and yields the zero configuration.
+func loadFileConfig(path, profile string) (fileConfig, error) {
+	var fc fileConfig
+	tree, err := loadConfigTree(path, profile)
+	if err != nil {
+		return fc, err
+	}
+	if _, err := decodeTree(tree, &fc); err != nil {
+		return fc, fmt.Errorf("config %s: %w", path, err)
+	}
+	return fc, nil
+}
+
+// loadConfigTree reads the config file at path and layers the
+// [profile.<name>] table of the named profile over the base settings.
+func loadConfigTree(path, profile string) (map[string]any, error) {
+	tree := make(map[string]any)
+	if _, err := toml.DecodeFile(path, &tree); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("config %s: %w", path, err)
+		}
+		if profile != "" {
+			return nil, fmt.Errorf("profile %q: config %s does not exist", profile, path)
+		}
+	}
+	base, profiles := splitProfiles(tree)
+	if profile == "" {
+		return base, nil
+	}
+	over, ok := profiles[profile]
+	if !ok {
+		names := slices.Sorted(maps.Keys(profiles))
+		if len(names) == 0 {
+			return nil, fmt.Errorf("profile %q: config %s defines no profiles", profile, path)
+		}
+		return nil, fmt.Errorf("profile %q: not in config %s (defined: %s)", profile, path, strings.Join(names, ", "))
+	}
+	return mergeTree(base, over), nil
+}
+
+// splitProfiles separates the [profile.<name>] tables from the base
+// settings.
+func splitProfiles(tree map[string]any) (base map[string]any, profiles map[string]map[string]any) {
+	base = make(map[string]any, len(tree))
+	for k, v := range tree {
+		if k != "profile" {
+			base[k] = v
+		}
+	}
+	profiles = make(map[string]map[string]any)
+	all, _ := tree["profile"].(map[string]any)
+	for name, p := range all {
+		if p, ok := p.(map[string]any); ok {
+			profiles[name] = p
+		}
+	}
+	return base, profiles
+}
+
+// mergeTree returns base with the keys of over replacing its own. Tables
+// are merged key by key, so a profile only lists what it changes.
+func mergeTree(base, over map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(over))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range over {
+		sub, isTable := v.(map[string]any)
+		if prev, ok := merged[k].(map[string]any); ok && isTable {
+			merged[k] = mergeTree(prev, sub)
+			continue
+		}
+		merged[k] = v
+	}
+	return merged
+}
+
+// profileDefines reports whether the named profile in the config file at
+// path sets keys, e.g. "blocklist", "channels".
+func profileDefines(path, profile string, keys ...string) bool {
+	tree := make(map[string]any)
+	if profile == "" {
+		return false
+	}
+	if _, err := toml.DecodeFile(path, &tree); err != nil {
+		return false
+	}
+	_, profiles := splitProfiles(tree)
+	return isDefined(profiles[profile], keys...)
+}
+
+// isDefined reports whether the nested keys exist in tree.
+func isDefined(tree map[string]any, keys ...string) bool {
+	for i, k := range keys {
+		v, ok := tree[k]
+		if !ok {
+			return false
+		}
+		if i == len(keys)-1 {
+			return true
+		}
+		if tree, ok = v.(map[string]any); !ok {
+			return false
+		}
+	}
+	return false
+}
+
+// decodeTree decodes a config tree into v the way toml.Decode would decode
+// the file it came from.
+func decodeTree(tree map[string]any, v any) (toml.MetaData, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(tree); err != nil {
+		return toml.MetaData{}, err
+	}
+	return toml.Decode(buf.String(), v)
+}
+
+// withConfig stores fc in ctx for the actions to pick up.
+func withConfig(ctx context.Context, fc fileConfig) context.Context {
+	return context.WithValue(ctx, configKey{}, fc)
+}
+
+// configFrom returns the configuration stored by withConfig.
+func configFrom(ctx context.Context) fileConfig {
+	fc, _ := ctx.Value(configKey{}).(fileConfig)
+	return fc
+}
+
+// setConfigValue sets key in [table] of the config file at path to the
+// already TOML-encoded value. Only the lines of that key are rewritten so
+// comments and formatting elsewhere survive. The result is decoded again
+// and a file this cannot edit safely, e.g. one keeping the table inline, is
+// left alone with an error saying what to add by hand.
+func setConfigValue(path, table, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	before := make(map[string]any)
+	if _, err := toml.Decode(string(data), &before); err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	target := append(splitKey(table), key)
+	lines = editConfigLines(lines, target, value)
+
+	after := make(map[string]any)
+	_, err = toml.Decode(strings.Join(lines, "\n"), &after)
+	if err != nil || !onlyChanged(before, after, target, value) {
+		return fmt.Errorf("config %s: cannot update %s safely, set it by hand to %s", path, strings.Join(target, "."), value)
+	}
+	return writeConfigLines(path, lines)
+}
+
+// editConfigLines replaces the key at the full dotted path target with
+// value, or adds it to its table, which is created when missing.
+func editConfigLines(lines []string, target []string, value string) []string {
+	parent := target[:len(target)-1]
+	var header []string
+	// Without the table a new one goes at the end
+	insertAt, insertKey := -1, target[len(parent):]
+	if len(parent) == 0 {
+		insertAt = 0
+	}
+	for i := 0; i < len(lines); i++ {
+		if h, ok := tableHeader(lines[i]); ok {
+			header = h
+			if slices.Equal(h, parent) {
+				insertAt, insertKey = i+1, target[len(h):]
+			}
+			continue
+		}
+		k := lineKey(lines[i])
+		if k == nil {
+			continue
+		}
+		full := append(slices.Clip(header), k...)
+		if slices.Equal(full, target) {
+			// Replace the key, including continuation lines of a multi-line array
+			end := i
+			for depth := bracketDepth(lines[i]); depth > 0 && end+1 < len(lines); {
+				end++
+				depth += bracketDepth(lines[end])
+			}
+			entry := strings.Join(quoteKey(k), ".") + " = " + value
+			return append(lines[:i], append([]string{entry}, lines[end+1:]...)...)
+		}
+		// A dotted key like blocklist.title_patterns in a parent table
+		// defines the table, a new [blocklist] header would clash with it
+		if len(header) < len(parent) && len(full) == len(target) && slices.Equal(full[:len(parent)], parent) {
+			insertAt, insertKey = i+1, target[len(header):]
+		}
+	}
+
+	entry := strings.Join(quoteKey(insertKey), ".") + " = " + value
+	if insertAt < 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		return append(lines, "["+strings.Join(quoteKey(parent), ".")+"]", entry)
+	}
+	return append(lines[:insertAt], append([]string{entry}, lines[insertAt:]...)...)
+}
+
+// onlyChanged reports whether after is before with the key at target set to
+// value and nothing else touched.
+func onlyChanged(before, after map[string]any, target []string, value string) bool {
+	want := make(map[string]any)
+	if _, err := toml.Decode("v = "+value, &want); err != nil {
+		return false
+	}
+	got, ok := lookupKey(after, target)
+	if !ok || !reflect.DeepEqual(got, want["v"]) {
+		return false
+	}
+	dropKey(before, target)
+	dropKey(after, target)
+	return reflect.DeepEqual(before, after)
+}
+
+func lookupKey(tree map[string]any, keys []string) (any, bool) {
+	for _, k := range keys[:len(keys)-1] {
+		sub, ok := tree[k].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		tree = sub
+	}
+	v, ok := tree[keys[len(keys)-1]]
+	return v, ok
+}
+
+// dropKey deletes the key at keys and the tables left empty by it.
+func dropKey(tree map[string]any, keys []string) {
+	if len(keys) > 1 {
+		sub, ok := tree[keys[0]].(map[string]any)
+		if !ok {
+			return
+		}
+		dropKey(sub, keys[1:])
+		if len(sub) > 0 {
+			return
+		}
+	}
+	delete(tree, keys[0])
+}
+
+// tableHeader returns the name of the table a [table] line opens. Array
+// tables get a name no key path can equal.
+func tableHeader(line string) ([]string, bool) {
+	line = strings.TrimSpace(stripComment(line))
+	if strings.HasPrefix(line, "[[") && strings.HasSuffix(line, "]]") {
+		return []string{"[[" + line[2:len(line)-2] + "]]"}, true
+	}
+	if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "]") {
+		return nil, false
+	}
+	return splitKey(line[1 : len(line)-1]), true
+}
+
+// lineKey returns the dotted key a "key = value" line sets, nil for other
+// lines.
+func lineKey(line string) []string {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "[") {
+		return nil
+	}
+	quote := rune(0)
+	for i, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '=':
+			return splitKey(line[:i])
+		}
+	}
+	return nil
+}
+
+// splitKey splits a dotted TOML key into its unquoted parts.
+func splitKey(key string) []string {
+	var parts []string
+	var part strings.Builder
+	quote := rune(0)
+	for _, r := range key {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			part.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '.':
+			parts = append(parts, strings.TrimSpace(part.String()))
+			part.Reset()
+		case r != ' ' && r != '\t':
+			part.WriteRune(r)
+		}
+	}
+	return append(parts, strings.TrimSpace(part.String()))
+}
+
+// quoteKey quotes the parts of a dotted key that are not bare keys.
+func quoteKey(parts []string) []string {
+	quoted := make([]string, len(parts))
+	for i, p := range parts {
+		quoted[i] = p
+		if !bareKeyRegex.MatchString(p) {
+			quoted[i] = tomlString(p)
+		}
+	}
+	return quoted
+}
+
+var bareKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// stripComment cuts a trailing comment off a line.
+func stripComment(line string) string {
+	quote := rune(0)
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// bracketDepth counts unbalanced square brackets outside of strings.
+func bracketDepth(line string) int {
+	depth := 0
+	for _, r := range stripStrings(stripComment(line)) {
+		switch r {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+	}
+	return depth
+}
+
+// stripStrings removes the quoted strings of a line, minding the escapes
+// of basic strings.
+func stripStrings(line string) string {
+	var b strings.Builder
+	quote := rune(0)
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote == '"' && r == '\\':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func writeConfigLines(path string, lines []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+}
+
+// tomlStringArray encodes ss as an inline TOML array of basic strings.
+func tomlStringArray(ss []string) string {
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		quoted[i] = tomlString(s)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// tomlString encodes s as a TOML basic string.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch {
+		case r == '"' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r == 0x7f:
+			fmt.Fprintf(&b, "\\u%04X", r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
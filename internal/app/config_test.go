@@ -0,0 +1,124 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetConfigValue(t *testing.T) {
+	tests := []struct {
+		name, table, file, want string
+	}{
+		{
+			name:  "missing table",
+			table: "blocklist",
+			file:  "quality = \"720p\"\n",
+			want:  "quality = \"720p\"\n\n[blocklist]\nchannels = [\"a\", \"b\"]\n",
+		},
+		{
+			name:  "empty file",
+			table: "blocklist",
+			file:  "",
+			want:  "[blocklist]\nchannels = [\"a\", \"b\"]\n",
+		},
+		{
+			name:  "header with a comment",
+			table: "blocklist",
+			file:  "[blocklist] # mine\nchannels = [\"a\"]\nmin_duration = \"60s\"\n",
+			want:  "[blocklist] # mine\nchannels = [\"a\", \"b\"]\nmin_duration = \"60s\"\n",
+		},
+		{
+			name:  "key added to the table",
+			table: "blocklist",
+			file:  "[ blocklist ]\nmin_duration = \"60s\"\n\n[network]\ntimeout = \"5s\"\n",
+			want:  "[ blocklist ]\nchannels = [\"a\", \"b\"]\nmin_duration = \"60s\"\n\n[network]\ntimeout = \"5s\"\n",
+		},
+		{
+			name:  "dotted key",
+			table: "blocklist",
+			file:  "quality = \"720p\"\nblocklist.channels = [\"a\"] # old\n",
+			want:  "quality = \"720p\"\nblocklist.channels = [\"a\", \"b\"]\n",
+		},
+		{
+			name:  "dotted sibling",
+			table: "blocklist",
+			file:  "blocklist.min_duration = \"60s\"\n\n[network]\ntimeout = \"5s\"\n",
+			want:  "blocklist.min_duration = \"60s\"\nblocklist.channels = [\"a\", \"b\"]\n\n[network]\ntimeout = \"5s\"\n",
+		},
+		{
+			name:  "escaped quotes in a multi-line array",
+			table: "blocklist",
+			file:  "[blocklist]\nchannels = [\n  \"a\\\"]\",\n  \"x\", # [\n]\ntitle_patterns = [\"y\"]\n",
+			want:  "[blocklist]\nchannels = [\"a\", \"b\"]\ntitle_patterns = [\"y\"]\n",
+		},
+		{
+			name:  "profile",
+			table: "profile.\"my.work\".blocklist",
+			file:  "[blocklist]\nchannels = [\"x\"]\n\n[profile.\"my.work\".blocklist]\nchannels = [\"a\"]\n",
+			want:  "[blocklist]\nchannels = [\"x\"]\n\n[profile.\"my.work\".blocklist]\nchannels = [\"a\", \"b\"]\n",
+		},
+		{
+			name:  "other table with the key",
+			table: "blocklist",
+			file:  "[fzf]\nchannels = 1\n",
+			want:  "[fzf]\nchannels = 1\n\n[blocklist]\nchannels = [\"a\", \"b\"]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := setConfigValue(path, tt.table, "channels", tomlStringArray([]string{"a", "b"})); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetConfigValueRefuses(t *testing.T) {
+	tests := []struct {
+		name, file string
+	}{
+		{"inline table", "blocklist = { channels = [\"a\"] }\n"},
+		{"invalid file", "[blocklist\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.toml")
+			if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			err := setConfigValue(path, "blocklist", "channels", tomlStringArray([]string{"a", "b"}))
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if got, _ := os.ReadFile(path); string(got) != tt.file {
+				t.Errorf("file changed to\n%s", got)
+			}
+		})
+	}
+}
+
+func TestSetConfigValueMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gophertube", "config.toml")
+	if err := setConfigValue(path, "blocklist", "channels", tomlStringArray([]string{"a"})); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[blocklist]\nchannels = [\"a\"]\n"; string(got) != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
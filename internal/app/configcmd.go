@@ -0,0 +1,477 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gophertube/config"
+	"gophertube/internal/services"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v3"
+)
+
+// configCommand groups the actions for the config file. They work on the
+// file named by --config and do not need it to be valid.
+func configCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "config",
+		Usage: "Create, inspect or check the config file",
+		Commands: []*cli.Command{
+			{
+				Name:   "path",
+				Usage:  "Print where the config file is read from",
+				Action: configPathAction,
+			},
+			{
+				Name:  "init",
+				Usage: "Write a commented config file with the defaults",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{Name: "force", Usage: "overwrite an existing file"},
+				},
+				Action: configInitAction,
+			},
+			{
+				Name:   "show",
+				Usage:  "Print the effective configuration and where each value comes from",
+				Action: configShowAction,
+			},
+			{
+				Name:   "edit",
+				Usage:  "Open the config file in $VISUAL or $EDITOR and check it afterwards",
+				Action: configEditAction,
+			},
+			{
+				Name:   "validate",
+				Usage:  "Report unknown keys and invalid values in the config file",
+				Action: configValidateAction,
+			},
+		},
+	}
+}
+
+func configPathAction(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.String(FlagConfig)
+	fmt.Println(path)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "The file does not exist yet, create it with `gophertube config init`.")
+	}
+	return nil
+}
+
+func configInitAction(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.String(FlagConfig)
+	if _, err := os.Stat(path); err == nil && !cmd.Bool("force") {
+		return fmt.Errorf("config %s already exists, use --force to overwrite it", path)
+	}
+	if err := writeDefaultConfig(path); err != nil {
+		return err
+	}
+	fmt.Printf("Wrote %s\n", path)
+	return nil
+}
+
+func writeDefaultConfig(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, config.Default, 0o644)
+}
+
+func configEditAction(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.String(FlagConfig)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err := writeDefaultConfig(path); err != nil {
+			return err
+		}
+	}
+	// The editor may come with arguments, e.g. "code --wait"
+	editor := strings.Fields(firstNonEmpty(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi"))
+	c := exec.CommandContext(ctx, editor[0], append(editor[1:], path)...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := c.Run(); err != nil {
+		return fmt.Errorf("editor: %w", err)
+	}
+	return reportConfigProblems(path, cmd.String(FlagProfile))
+}
+
+func configValidateAction(ctx context.Context, cmd *cli.Command) error {
+	return reportConfigProblems(cmd.String(FlagConfig), cmd.String(FlagProfile))
+}
+
+// reportConfigProblems prints what is wrong with the config file at path and
+// fails when anything is. profile is the profile selected, if any.
+func reportConfigProblems(path, profile string) error {
+	problems, err := checkConfig(path, profile)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		fmt.Printf("%s: OK\n", path)
+		return nil
+	}
+	for _, p := range problems {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, p)
+	}
+	return fmt.Errorf("config %s has %d problem(s)", path, len(problems))
+}
+
+// checkConfig lists the problems of the config file at path: syntax errors,
+// unknown keys and values the app would refuse to start with, in the base
+// settings and in every profile, and a selected profile it does not define.
+func checkConfig(path, profile string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	type settings struct {
+		topLevelConfig
+		fileConfig
+	}
+	var raw struct {
+		settings
+		Profile map[string]settings `toml:"profile"`
+	}
+	md, err := toml.Decode(string(data), &raw)
+	if err != nil {
+		// Syntax and type errors stop the decoder, nothing else is known
+		return []string{err.Error()}, nil
+	}
+
+	var problems []string
+	var unknown []string
+	for _, key := range md.Undecoded() {
+		// The keys of an unknown table are not worth a line each
+		if len(unknown) > 0 && strings.HasPrefix(key.String(), unknown[len(unknown)-1]+".") {
+			continue
+		}
+		unknown = append(unknown, key.String())
+		problems = append(problems, fmt.Sprintf("unknown key %q", key.String()))
+	}
+
+	tree := make(map[string]any)
+	if _, err := toml.Decode(string(data), &tree); err != nil {
+		return nil, err
+	}
+	base, profiles := splitProfiles(tree)
+	if _, ok := profiles[profile]; profile != "" && !ok {
+		problems = append(problems, fmt.Sprintf("profile %q is not defined", profile))
+	}
+	inBase := make(map[string]bool)
+	for _, p := range checkValues(base) {
+		inBase[p] = true
+		problems = append(problems, p)
+	}
+	for _, name := range slices.Sorted(maps.Keys(profiles)) {
+		for _, p := range checkValues(mergeTree(base, profiles[name])) {
+			if !inBase[p] {
+				problems = append(problems, fmt.Sprintf("profile %s: %s", name, p))
+			}
+		}
+	}
+	return problems, nil
+}
+
+// checkValues lists the values in tree the app would refuse to start with.
+func checkValues(tree map[string]any) []string {
+	var problems []string
+	check := func(err error) {
+		if err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	var c struct {
+		topLevelConfig
+		fileConfig
+	}
+	if _, err := decodeTree(tree, &c); err != nil {
+		check(err)
+		return problems
+	}
+
+	top, fc := c.topLevelConfig, c.fileConfig
+	if isDefined(tree, "search_limit") && top.SearchLimit < 1 {
+		check(fmt.Errorf("search_limit must be at least 1, got %d", top.SearchLimit))
+	}
+	if isDefined(tree, "thumb_cache_size") && top.ThumbCacheSize < 0 {
+		check(fmt.Errorf("thumb_cache_size must not be negative, got %d", top.ThumbCacheSize))
+	}
+	if isDefined(tree, "search_cache_ttl") {
+		if _, err := time.ParseDuration(top.SearchCacheTTL); err != nil {
+			check(fmt.Errorf("search_cache_ttl: invalid duration %q", top.SearchCacheTTL))
+		}
+	}
+	if isDefined(tree, "quality") {
+		check(fc.Ytdlp.validQuality(top.Quality))
+	}
+	_, err := services.ParseLocale(
+		firstNonEmpty(top.Language, services.DefaultLocale.Language),
+		firstNonEmpty(top.Region, services.DefaultLocale.Region),
+	)
+	check(err)
+	check(services.CheckCookies(services.CookieConfig{
+		File:    expandPath(top.Cookies),
+		Browser: top.CookiesFromBrowser,
+	}))
+
+	_, err = newBlocklist(fc.Blocklist)
+	check(err)
+	if nc, err := fc.Network.resolve(); err != nil {
+		check(err)
+	} else {
+		check(services.CheckNetwork(nc))
+	}
+	_, err = newKeyMap(fc.Keys)
+	check(err)
+	_, err = fc.Theme.resolve(false)
+	check(err)
+	check(fc.Ytdlp.validate())
+	check(fc.Thumbnails.validate())
+	check(fc.Audio.validate())
+	return problems
+}
+
+func configShowAction(ctx context.Context, cmd *cli.Command) error {
+	path, profile := cmd.String(FlagConfig), cmd.String(FlagProfile)
+	tree, err := loadConfigTree(path, profile)
+	if err != nil {
+		return err
+	}
+	var c struct {
+		topLevelConfig
+		fileConfig
+	}
+	if _, err := decodeTree(tree, &c); err != nil {
+		return fmt.Errorf("config %s: %w", path, err)
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("# %s (%s, not created yet)\n", path, flagSource(cmd, FlagConfig))
+	} else {
+		fmt.Printf("# %s (%s)\n", path, flagSource(cmd, FlagConfig))
+	}
+	if profile != "" {
+		fmt.Printf("# profile %s (%s)\n", profile, flagSource(cmd, FlagProfile))
+	}
+
+	// fileSource tells whether keys come from the profile or the base
+	// settings, "" when the file does not set them
+	raw := make(map[string]any)
+	toml.DecodeFile(path, &raw)
+	base, profiles := splitProfiles(raw)
+	fileSource := func(keys ...string) string {
+		switch {
+		case profile != "" && isDefined(profiles[profile], keys...):
+			return "profile " + profile
+		case isDefined(base, keys...):
+			return "config file"
+		}
+		return ""
+	}
+
+	var lines [][2]string // entry and source
+	add := func(entry, source string) {
+		lines = append(lines, [2]string{entry, source})
+	}
+	flush := func() {
+		width := 0
+		for _, l := range lines {
+			width = max(width, len(l[0]))
+		}
+		for _, l := range lines {
+			if l[1] == "" {
+				fmt.Println(l[0])
+				continue
+			}
+			fmt.Printf("%-*s  # %s\n", width, l[0], l[1])
+		}
+		lines = nil
+	}
+
+	// Plain settings go through the flags, which already merged the file,
+	// the environment and the command line
+	fields := reflect.TypeOf(c.topLevelConfig)
+	for i := 0; i < fields.NumField(); i++ {
+		f := fields.Field(i)
+		key, flag := f.Tag.Get("toml"), f.Tag.Get("flag")
+		source := flagSource(cmd, flag)
+		if source == "default" {
+			source = firstNonEmpty(fileSource(key), source)
+		}
+		add(key+" = "+tomlValue(cmd.Value(flag)), source)
+	}
+	flush()
+
+	effective, defined := effectiveConfig(c.fileConfig, tree)
+	tables := reflect.ValueOf(effective)
+	for i := 0; i < tables.NumField(); i++ {
+		table := tables.Type().Field(i).Tag.Get("toml")
+		fmt.Printf("\n[%s]\n", table)
+		values := tables.Field(i)
+		var nested []int
+		for j := 0; j < values.NumField(); j++ {
+			if values.Field(j).Kind() == reflect.Map {
+				nested = append(nested, j)
+				continue
+			}
+			key := values.Type().Field(j).Tag.Get("toml")
+			source := "default"
+			if defined[table+"."+key] {
+				source = fileSource(table, key)
+			}
+			add(key+" = "+tomlValue(values.Field(j).Interface()), source)
+		}
+		// Nested tables like [ytdlp.formats] follow the plain keys
+		for _, j := range nested {
+			key, m := values.Type().Field(j).Tag.Get("toml"), values.Field(j)
+			add("["+table+"."+key+"]", "")
+			for _, name := range sortedKeys(m) {
+				source := firstNonEmpty(fileSource(table, key, name), "default")
+				add(tomlString(name)+" = "+tomlValue(m.MapIndex(reflect.ValueOf(name)).Interface()), source)
+			}
+		}
+		flush()
+	}
+	return nil
+}
+
+// effectiveConfig fills the keys fc, decoded from tree, leaves unset with
+// their defaults. Empty strings and zero numbers mean the default as well,
+// empty lists do not. It also returns the "table.key" names whose value
+// comes from the file.
+func effectiveConfig(fc fileConfig, tree map[string]any) (fileConfig, map[string]bool) {
+	theme := strings.ToLower(strings.TrimSpace(fc.Theme.Name))
+	if _, ok := themes[theme]; !ok {
+		theme = "dark"
+	}
+	effective := defaultFileConfig(theme)
+	defined := make(map[string]bool)
+
+	dst, src := reflect.ValueOf(&effective).Elem(), reflect.ValueOf(fc)
+	for i := 0; i < src.NumField(); i++ {
+		table := src.Type().Field(i).Tag.Get("toml")
+		for j := 0; j < src.Field(i).NumField(); j++ {
+			key := src.Field(i).Type().Field(j).Tag.Get("toml")
+			v := src.Field(i).Field(j)
+			if isDefined(tree, table, key) && !(v.IsZero() && (v.Kind() == reflect.String || v.Kind() == reflect.Int)) {
+				defined[table+"."+key] = true
+				dst.Field(i).Field(j).Set(v)
+			}
+		}
+	}
+	// Formats from the file add to the built-in ones
+	effective.Ytdlp.Formats = fc.Ytdlp.withDefaults().Formats
+	return effective, defined
+}
+
+// defaultFileConfig is the configuration used for tables missing from the
+// file, with the colors of the named built-in theme.
+func defaultFileConfig(themeName string) fileConfig {
+	nc := services.DefaultNetworkConfig
+	t := themes[themeName]
+	enabled := true
+	return fileConfig{
+		Blocklist: blocklistConfig{Channels: []string{}, TitlePatterns: []string{}},
+		Network: networkConfig{
+			Timeout:          nc.Timeout.String(),
+			ThumbnailTimeout: nc.ThumbnailTimeout.String(),
+			Retries:          &nc.Retries,
+			RetryDelay:       nc.RetryDelay.String(),
+			MaxRetryDelay:    nc.MaxRetryDelay.String(),
+			ThumbConcurrency: nc.ThumbnailConcurrency,
+		},
+		Keys: defaultKeys,
+		Theme: themeConfig{
+			Name:   themeName,
+			Accent: t.Accent, Info: t.Info, Success: t.Success, Notice: t.Notice,
+			Error: t.Error, Text: t.Text, Muted: t.Muted,
+			FzfColors:     t.FzfColors,
+			Border:        t.Border,
+			Margin:        t.Margin,
+			PreviewWindow: t.PreviewWindow,
+			PreviewWidth:  t.PreviewWidth,
+			PreviewHeight: t.PreviewHeight,
+		},
+		Player:     defaultPlayer,
+		Fzf:        defaultFzf,
+		Ytdlp:      defaultYtdlp,
+		Thumbnails: thumbnailsConfig{Enabled: &enabled},
+	}
+}
+
+// flagSource tells where the value of the flag called name came from:
+// "default", the command line or the environment. Values from the config
+// file are told apart by the caller.
+func flagSource(cmd *cli.Command, name string) string {
+	var flag cli.Flag
+	for _, f := range cmd.Root().Flags {
+		for _, n := range f.Names() {
+			if n == name {
+				flag = f
+			}
+		}
+	}
+	if flag == nil || !cmd.IsSet(name) {
+		return "default"
+	}
+	for _, arg := range os.Args[1:] {
+		if arg == "--" {
+			break
+		}
+		for _, n := range flag.Names() {
+			for _, prefix := range []string{"-", "--"} {
+				if arg == prefix+n || strings.HasPrefix(arg, prefix+n+"=") {
+					return "--" + name
+				}
+			}
+		}
+	}
+	if vs, ok := flag.(interface{ GetEnvVars() []string }); ok {
+		for _, env := range vs.GetEnvVars() {
+			if _, ok := os.LookupEnv(env); ok {
+				return "$" + env
+			}
+		}
+	}
+	return "default"
+}
+
+// tomlValue encodes the config value v in TOML.
+func tomlValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return tomlString(v)
+	case []string:
+		return tomlStringArray(v)
+	case time.Duration:
+		return tomlString(v.String())
+	case *int:
+		if v == nil {
+			return "0"
+		}
+		return fmt.Sprint(*v)
+	case *bool:
+		return fmt.Sprint(v == nil || *v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// sortedKeys returns the keys of the map[string]... m in order.
+func sortedKeys(m reflect.Value) []string {
+	keys := make([]string, 0, m.Len())
+	for _, k := range m.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
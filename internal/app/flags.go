@@ -2,22 +2,32 @@ package app
 
 import (
 	"errors"
+	"gophertube/internal/services"
 	"os"
+	"path/filepath"
 
-	"github.com/urfave/cli-altsrc/v3"
-	toml "github.com/urfave/cli-altsrc/v3/toml"
 	"github.com/urfave/cli/v3"
 )
 
 // Flag names are constant since they are also used as keys to query the data.
 // This ensures a single source of truth.
 const (
-	FlagQuality       = "quality"
-	FlagSearchLimit   = "search-limit"
-	FlagConfig        = "config"
-	FlagDownloadsPath = "downloads-path"
+	FlagQuality        = "quality"
+	FlagSearchLimit    = "search-limit"
+	FlagConfig         = "config"
+	FlagProfile        = "profile"
+	FlagDownloadsPath  = "downloads-path"
+	FlagThumbCacheSize = "thumb-cache-size"
+	FlagSearchCacheTTL = "search-cache-ttl"
+	FlagNoCache        = "no-cache"
+	FlagOffline        = "offline"
+	FlagRegion         = "region"
+	FlagLanguage       = "language"
+	FlagCookies        = "cookies"
+	FlagCookiesBrowser = "cookies-from-browser"
+	FlagDebug          = "debug"
 
-	defaultConfigPath    = "$HOME/.config/gophertube/gophertube.toml"
+	defaultConfigPath    = "$XDG_CONFIG_HOME/gophertube/gophertube.toml"
 	defaultDownloadsPath = "$HOME/Videos/GopherTube"
 )
 
@@ -26,7 +36,7 @@ var (
 )
 
 func Flags() []cli.Flag {
-	var confDir string
+	var conf configLocation
 
 	// --help and -version flags are free, no need to set them up :)
 	return []cli.Flag{
@@ -34,56 +44,96 @@ func Flags() []cli.Flag {
 			Name:        FlagConfig,
 			Aliases:     []string{"c"},
 			Sources:     cli.EnvVars("GOPHERTUBE_CONFIG"),
-			Value:       os.ExpandEnv(defaultConfigPath),
+			Value:       filepath.Join(services.DefaultConfigDir(), "gophertube.toml"),
 			DefaultText: defaultConfigPath, // otherwise `--help` prints it expanded
-			Destination: &confDir,
+			Destination: &conf.path,
 			TakesFile:   true,
 		},
+		// Before every flag that reads the config file
 		&cli.StringFlag{
-			Name:      FlagDownloadsPath,
-			Aliases:   []string{"d"},
-			TakesFile: true,
-			Sources: cli.NewValueSourceChain(
-				toml.TOML("downloads_path", altsrc.NewStringPtrSourcer(&confDir)),
-			),
+			Name:        FlagProfile,
+			Aliases:     []string{"p"},
+			Usage:       "apply the [profile.<name>] table of the config file over the base settings",
+			Sources:     cli.EnvVars("GOPHERTUBE_PROFILE"),
+			Destination: &conf.profile,
+		},
+		&cli.StringFlag{
+			Name:        FlagDownloadsPath,
+			Aliases:     []string{"d"},
+			TakesFile:   true,
+			Sources:     configSource("downloads_path", &conf),
 			Value:       os.ExpandEnv(defaultDownloadsPath),
 			DefaultText: defaultDownloadsPath, // otherwise `--help` prints it expanded
 		},
 		&cli.IntFlag{
 			Name:    FlagSearchLimit,
 			Aliases: []string{"l"},
-			Sources: cli.NewValueSourceChain(
-				toml.TOML("search_limit", altsrc.NewStringPtrSourcer(&confDir)),
-			),
-			Value: 30,
+			Sources: configSource("search_limit", &conf),
+			Value:   30,
 		},
 		&cli.StringFlag{
 			Name:    FlagQuality,
 			Aliases: []string{"q"},
-			Sources: cli.NewValueSourceChain(
-				toml.TOML("quality", altsrc.NewStringPtrSourcer(&confDir)),
-			),
-			Value:     "720p",
-			Validator: IsValidQualityFmt,
+			Usage:   "height like 720p or 1080p60, a name from [ytdlp.formats] or a yt-dlp format",
+			Sources: configSource("quality", &conf),
+			Value:   "720p",
+		},
+		&cli.IntFlag{
+			Name:    FlagThumbCacheSize,
+			Usage:   "thumbnail cache limit in MiB, 0 for unlimited",
+			Sources: configSource("thumb_cache_size", &conf),
+			Value:   services.DefaultThumbCacheSize >> 20,
+		},
+		&cli.DurationFlag{
+			Name:    FlagSearchCacheTTL,
+			Usage:   "how long search results are reused before asking YouTube again",
+			Sources: configSource("search_cache_ttl", &conf),
+			Value:   services.DefaultSearchCacheTTL,
+		},
+		&cli.BoolFlag{
+			Name:  FlagNoCache,
+			Usage: "always fetch fresh search results and do not store them",
+		},
+		&cli.BoolFlag{
+			Name:  FlagOffline,
+			Usage: "work from downloads, history and caches without using the network",
+		},
+		&cli.StringFlag{
+			Name:    FlagRegion,
+			Usage:   "region YouTube results are localized for, e.g. US, DE or IN",
+			Sources: configSource("region", &conf),
+			Value:   services.DefaultLocale.Region,
+		},
+		&cli.StringFlag{
+			Name:    FlagLanguage,
+			Usage:   "interface language of YouTube results, e.g. en, de or pt-BR",
+			Sources: configSource("language", &conf),
+			Value:   services.DefaultLocale.Language,
+		},
+		&cli.BoolFlag{
+			Name:  FlagDebug,
+			Usage: "save every response from YouTube for bug reports",
+		},
+		&cli.StringFlag{
+			Name:      FlagCookies,
+			Usage:     "Netscape cookies.txt sent to YouTube, also passed to yt-dlp and mpv",
+			TakesFile: true,
+			Sources:   configSource("cookies", &conf),
+		},
+		&cli.StringFlag{
+			Name:    FlagCookiesBrowser,
+			Usage:   "read cookies from a browser profile through yt-dlp, e.g. firefox or chrome:Profile 1",
+			Sources: configSource("cookies_from_browser", &conf),
 		},
 	}
 }
 
-// Ensure argument format follows the "<int>p" pattern.
-// Ex: 720p, 1080p, etc...
+// Ensure argument format follows the "<int>p" pattern, optionally followed
+// by a framerate.
+// Ex: 720p, 1080p, 1080p60, etc...
 func IsValidQualityFmt(s string) error {
-	// Check if string ends with 'p'
-	if len(s) < 2 || s[len(s)-1] != 'p' {
+	if _, _, ok := parseQuality(s); !ok {
 		return errQualityFormat
 	}
-
-	// Check if everything before 'p' is a valid integer
-	numPart := s[:len(s)-1]
-	for _, char := range numPart {
-		if char < '0' || char > '9' {
-			return errQualityFormat
-		}
-	}
-
 	return nil
 }
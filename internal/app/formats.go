@@ -0,0 +1,158 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// videoCodecs maps the codec names of [ytdlp] codecs to a yt-dlp filter
+// matching the vcodec of their streams, e.g. "av01.0.08M.08" for av1.
+var videoCodecs = map[string]string{
+	"av1":  "[vcodec^=av01]",
+	"vp9":  "[vcodec~='^vp0?9']",
+	"h264": "[vcodec~='^(avc1|h264)']",
+	"h265": "[vcodec~='^(hvc1|hev1|hevc|h265)']",
+}
+
+// containers are the values of [ytdlp] container. mkv holds any codec so it
+// only decides what downloads are merged into.
+var containers = []string{"mp4", "webm", "mkv"}
+
+// hdrModes are the values of [ytdlp] hdr and the streams they look for
+// first. Empty leaves it to yt-dlp, which picks HDR streams when they are
+// the best ones.
+var hdrModes = map[string]string{
+	"":       "",
+	"prefer": "[dynamic_range!=SDR]",
+	"avoid":  "[dynamic_range=?SDR]",
+	"only":   "[dynamic_range!=SDR]",
+}
+
+// ytdlpKeywords are the yt-dlp format selectors that are a single word.
+var ytdlpKeywords = []string{
+	"best", "worst", "bestvideo", "worstvideo", "bestaudio", "worstaudio",
+	"b", "w", "bv", "wv", "ba", "wa", "b*", "w*", "bv*", "wv*", "ba*", "wa*",
+	"mergeall", "all",
+}
+
+// parseQuality reads a quality like "1440p" or "1080p60" into its height
+// and framerate, 0 when not given.
+func parseQuality(q string) (height, fps int, ok bool) {
+	h, f, found := strings.Cut(q, "p")
+	height, err := strconv.Atoi(h)
+	if !found || err != nil || height <= 0 || strings.HasPrefix(h, "+") {
+		return 0, 0, false
+	}
+	if f == "" {
+		return height, 0, true
+	}
+	fps, err = strconv.Atoi(f)
+	if err != nil || fps <= 0 || strings.HasPrefix(f, "+") {
+		return 0, 0, false
+	}
+	return height, fps, true
+}
+
+// isRawFormat reports whether q is a yt-dlp format selector rather than a
+// quality name, e.g. "bv*[height<=1440]+ba/b".
+func isRawFormat(q string) bool {
+	return strings.ContainsAny(q, "[]+/,()") || slices.Contains(ytdlpKeywords, q)
+}
+
+// format returns the format selector for quality: the entry in formats, a
+// selector built from the preferences of c for heights like "1440p" or
+// "1080p60", or quality itself when it already is a selector.
+func (c ytdlpConfig) format(quality string) string {
+	c = c.withDefaults()
+	if f, ok := c.Formats[quality]; ok {
+		return f
+	}
+	if height, fps, ok := parseQuality(quality); ok {
+		return c.selector(height, fps)
+	}
+	if isRawFormat(quality) {
+		return quality
+	}
+	return c.selector(0, 0)
+}
+
+// selector builds the format for videos up to height pixels and fps frames
+// per second, 0 for no limit, trying the preferred codecs, container and
+// dynamic range first and falling back to whatever is there.
+func (c ytdlpConfig) selector(height, fps int) string {
+	var limits string
+	if height > 0 {
+		limits += fmt.Sprintf("[height<=%d]", height)
+	}
+	if c.Fps > 0 && (fps == 0 || c.Fps < fps) {
+		fps = c.Fps
+	}
+	if fps > 0 {
+		limits += fmt.Sprintf("[fps<=%d]", fps)
+	}
+	hdr := hdrModes[strings.ToLower(c.HDR)]
+
+	// Preferences from most to least important, each falling back to none
+	dynamic := []string{""}
+	switch strings.ToLower(c.HDR) {
+	case "prefer", "avoid":
+		dynamic = []string{hdr, ""}
+	case "only":
+		limits += hdr
+	}
+	codecs := make([]string, 0, len(c.Codecs)+1)
+	for _, name := range c.Codecs {
+		codecs = append(codecs, videoCodecs[strings.ToLower(name)])
+	}
+	codecs = append(codecs, "")
+	exts := []string{""}
+	if ext := strings.ToLower(c.Container); ext != "" && ext != "mkv" {
+		exts = []string{"[ext=" + ext + "]", ""}
+	}
+
+	var alternatives []string
+	for _, d := range dynamic {
+		for _, codec := range codecs {
+			for _, ext := range exts {
+				alternatives = append(alternatives, "bestvideo"+limits+d+codec+ext+"+bestaudio")
+			}
+		}
+	}
+	alternatives = append(alternatives, "best"+limits)
+	return strings.Join(slices.Compact(alternatives), "/")
+}
+
+// mergeFormat is the container downloaded video and audio are merged into.
+func (c ytdlpConfig) mergeFormat() string {
+	return firstNonEmpty(strings.ToLower(c.Container), "mp4")
+}
+
+// validQuality reports qualities that are neither a height, a name in
+// formats nor a yt-dlp format selector.
+func (c ytdlpConfig) validQuality(quality string) error {
+	if _, ok := c.withDefaults().Formats[quality]; ok || IsValidQualityFmt(quality) == nil || isRawFormat(quality) {
+		return nil
+	}
+	return fmt.Errorf("invalid quality %q: use a height like 720p or 1080p60, a name from [ytdlp.formats] or a yt-dlp format", quality)
+}
+
+// validatePreferences reports unknown codecs, containers and HDR modes.
+func (c ytdlpConfig) validatePreferences() error {
+	for _, name := range c.Codecs {
+		if _, ok := videoCodecs[strings.ToLower(name)]; !ok {
+			return fmt.Errorf("ytdlp: unknown codec %q (av1, vp9, h264 or h265)", name)
+		}
+	}
+	if c.Container != "" && !slices.Contains(containers, strings.ToLower(c.Container)) {
+		return fmt.Errorf("ytdlp: unknown container %q (%s)", c.Container, strings.Join(containers, ", "))
+	}
+	if _, ok := hdrModes[strings.ToLower(c.HDR)]; !ok {
+		return fmt.Errorf("ytdlp: unknown hdr %q (prefer, avoid or only)", c.HDR)
+	}
+	if c.Fps < 0 {
+		return fmt.Errorf("ytdlp: fps must not be negative, got %d", c.Fps)
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+package app
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/chzyer/readline"
+)
+
+var ansiRegex = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
+
+// fieldRegex matches the field numbers of a --with-nth expression, and
+// placeholderRegex the field placeholders of a preview command like {2} or
+// {3..}.
+var (
+	fieldRegex       = regexp.MustCompile(`-?\d+`)
+	placeholderRegex = regexp.MustCompile(`\{\+?s?-?\d*(\.\.-?\d*)?\}`)
+)
+
+// fzfUI is the terminal implementation of UI. Lists are shown with fzf,
+// everything else is written straight to the terminal.
+type fzfUI struct {
+	ctx  context.Context
+	path string   // resolved fzf binary
+	args []string // configured extra options
+
+	noListen bool // fzf rejected --listen, headers are not refreshed
+}
+
+func newFzfUI(ctx context.Context, path string, args []string) *fzfUI {
+	return &fzfUI{ctx: ctx, path: path, args: args}
+}
+
+// run shows items in fzf and returns the lines it printed. Every item is
+// prefixed with its index as a hidden first field, so a selection maps back
+// to its item even when two lines read the same; the fields of opts are
+// shifted past it.
+func (f *fzfUI) run(opts PickOptions, items []string, extra ...string) ([]string, error) {
+	delimiter, withNth := fzfDelimiter(opts), "2.."
+	if opts.WithNth != "" {
+		withNth = shiftFields(opts.WithNth)
+	}
+	args := []string{"--delimiter=" + delimiter, "--with-nth=" + withNth}
+	if fzfColors != "" {
+		args = append(args, "--color="+fzfColors)
+	}
+	if opts.Prompt != "" {
+		args = append(args, "--prompt="+opts.Prompt)
+	}
+	if opts.Ansi {
+		args = append(args, "--ansi")
+	}
+	if opts.Header != "" {
+		args = append(args, "--header="+opts.Header)
+	}
+	if len(opts.Expect) > 0 {
+		args = append(args, "--expect="+strings.Join(opts.Expect, ","), "--bind=esc:abort")
+	}
+	if opts.Preview != "" {
+		args = append(args,
+			"--border="+fzfBorder,
+			"--margin="+fzfMargin,
+			"--preview-window="+fzfPreviewWrap,
+			"--preview", placeholderRegex.ReplaceAllStringFunc(opts.Preview, shiftFields),
+		)
+	}
+	if opts.Query != "" {
+		args = append(args, "--query="+opts.Query)
+	}
+	args = append(args, extra...)
+	args = append(args, f.args...)
+
+	var input strings.Builder
+	for i, it := range items {
+		fmt.Fprintf(&input, "%d%s%s\n", i, delimiter, it)
+	}
+	cmd := command(f.ctx, f.path, args...)
+	cmd.Stdin = strings.NewReader(input.String())
+	cmd.Stderr = os.Stderr
+	stopHeader := func() {}
+	live := opts.LiveHeader != nil && !f.noListen
+	if live {
+		port, key, err := listenPort()
+		if err != nil {
+			live = false
+		} else {
+			cmd.Args = append(cmd.Args, "--listen="+strconv.Itoa(port))
+			cmd.Env = append(os.Environ(), "FZF_API_KEY="+key)
+			stopHeader = refreshHeader(port, key, opts.Header, opts.LiveHeader)
+		}
+	}
+	out, err := cmd.Output()
+	stopHeader()
+	if err != nil {
+		var exit *exec.ExitError
+		if live && errors.As(err, &exit) && exit.ExitCode() == 2 {
+			// fzf before 0.36 has no --listen, the header stays as it is
+			f.noListen = true
+			return f.run(opts, items, extra...)
+		}
+		// fzf exits non-zero on Esc/Ctrl+C and when nothing matched
+		return nil, ErrCancelled
+	}
+	return strings.Split(strings.TrimRight(string(out), "\n"), "\n"), nil
+}
+
+// listenPort picks a free local port for fzf's HTTP server and the key
+// other local users would need to send it actions.
+func listenPort() (int, string, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, "", err
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return 0, "", err
+	}
+	return port, hex.EncodeToString(key), nil
+}
+
+// refreshHeader has the fzf listening on port show header() every second
+// for as long as it changes, until stop is called.
+func refreshHeader(port int, key, shown string, header func() string) (stop func()) {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		// A bare transport, the configured proxy must not see local traffic
+		client := &http.Client{Transport: &http.Transport{}, Timeout: time.Second}
+		tick := time.NewTicker(time.Second)
+		defer tick.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-tick.C:
+			}
+			h := header()
+			if h == shown {
+				continue
+			}
+			req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("http://127.0.0.1:%d", port), strings.NewReader("change-header:"+h))
+			if err != nil {
+				continue
+			}
+			req.Header.Set("x-api-key", key)
+			// fzf may not listen yet or be gone already, the next tick retries
+			if resp, err := client.Do(req); err == nil {
+				resp.Body.Close()
+				shown = h
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// fzfDelimiter separates the fields of the lines given to fzf.
+func fzfDelimiter(opts PickOptions) string {
+	return firstNonEmpty(opts.Delimiter, "\t")
+}
+
+// shiftFields moves the positive field numbers in expr one field right,
+// past the index run adds. Negative ones count from the end and stay.
+func shiftFields(expr string) string {
+	return fieldRegex.ReplaceAllStringFunc(expr, func(n string) string {
+		if strings.HasPrefix(n, "-") {
+			return n
+		}
+		i, _ := strconv.Atoi(n)
+		return strconv.Itoa(i + 1)
+	})
+}
+
+// indexOf reads the index run put in front of a line printed by fzf, -1
+// when it is not one of the n items.
+func indexOf(line, delimiter string, n int) int {
+	field, _, _ := strings.Cut(line, delimiter)
+	i, err := strconv.Atoi(field)
+	if err != nil || i < 0 || i >= n {
+		return -1
+	}
+	return i
+}
+
+func (f *fzfUI) ChooseOne(opts PickOptions, items []string) (Choice, error) {
+	lines, err := f.run(opts, items)
+	if err != nil {
+		return Choice{Index: -1}, err
+	}
+	c := Choice{Index: -1}
+	if len(opts.Expect) > 0 {
+		c.Key = lines[0]
+		lines = lines[1:]
+	}
+	if len(lines) > 0 && lines[0] != "" {
+		c.Index = indexOf(lines[0], fzfDelimiter(opts), len(items))
+	}
+	if c.Index < 0 && c.Key == "" {
+		return c, ErrCancelled
+	}
+	return c, nil
+}
+
+func (f *fzfUI) ChooseMany(opts PickOptions, items []string) ([]int, error) {
+	lines, err := f.run(opts, items, "--multi")
+	if err != nil {
+		return nil, err
+	}
+	var picked []int
+	for _, l := range lines {
+		if i := indexOf(l, fzfDelimiter(opts), len(items)); i >= 0 {
+			picked = append(picked, i)
+		}
+	}
+	if len(picked) == 0 {
+		return nil, ErrCancelled
+	}
+	return picked, nil
+}
+
+func (f *fzfUI) PromptText(prompt string) (string, error) {
+	type result struct {
+		query string
+		esc   bool
+	}
+	done := make(chan result, 1)
+	go func() {
+		query, esc := readQuery(prompt)
+		done <- result{query, esc}
+	}()
+	var r result
+	select {
+	case r = <-done:
+	case <-f.ctx.Done():
+		// Action restores the terminal on the way out
+		return "", ErrCancelled
+	}
+	if r.esc || r.query == "" {
+		fmt.Print("\033[2J\033[H")
+		return "", ErrCancelled
+	}
+	return r.query, nil
+}
+
+func (f *fzfUI) ShowMessage(msg string) {
+	fmt.Println("    " + msg)
+}
+
+func (f *fzfUI) ShowProgress(current, total int) {
+	printProgressBar(current, total)
+}
+
+func (f *fzfUI) Pause(msg string) {
+	fmt.Println("    " + colorText + msg + colorReset)
+	read := make(chan struct{})
+	go func() {
+		os.Stdin.Read(make([]byte, 1))
+		close(read)
+	}()
+	select {
+	case <-read:
+	case <-f.ctx.Done():
+	}
+}
+
+func (f *fzfUI) DrawScreen(lines []string) {
+	var b strings.Builder
+	b.WriteString("\033[H\033[2J\n")
+	for _, l := range lines {
+		b.WriteString("    " + l + "\n")
+	}
+	fmt.Print(b.String())
+}
+
+// ScreenWidth leaves room for the indent of DrawScreen.
+func (f *fzfUI) ScreenWidth() int {
+	return readline.GetScreenWidth() - 8
+}
+
+func (f *fzfUI) WatchKeys(onKey func(key string) bool) (stop func()) {
+	return watchKeys(func(key []byte) bool {
+		return onKey(string(key))
+	})
+}
+
+// clipboardCommands are tried in order, the first one installed wins.
+var clipboardCommands = [][]string{
+	{"wl-copy"},
+	{"xclip", "-selection", "clipboard"},
+	{"xsel", "--clipboard", "--input"},
+	{"pbcopy"},
+}
+
+// CopyToClipboard runs the first clipboard tool installed. Without one, e.g.
+// over SSH, the terminal is asked to do it with OSC 52.
+func (f *fzfUI) CopyToClipboard(text string) {
+	for _, c := range clipboardCommands {
+		if c[0] == "wl-copy" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			continue
+		}
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		cmd := exec.Command(c[0], c[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		if cmd.Run() == nil {
+			return
+		}
+	}
+	fmt.Printf("\033]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(text)))
+}
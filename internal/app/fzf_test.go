@@ -0,0 +1,50 @@
+package app
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRefreshHeader(t *testing.T) {
+	posted := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		posted <- string(body)
+	}))
+	defer srv.Close()
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+
+	var calls atomic.Int32
+	stop := refreshHeader(port, "secret", "playing 0:01", func() string {
+		if calls.Add(1) == 1 {
+			return "playing 0:01" // unchanged, not sent
+		}
+		return "playing 0:02\nnext line"
+	})
+	select {
+	case got := <-posted:
+		if want := "change-header:playing 0:02\nnext line"; got != want {
+			t.Errorf("posted %q, want %q", got, want)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("header not refreshed")
+	}
+	stop()
+	if calls.Load() < 2 {
+		t.Errorf("header asked for %d times, want the unchanged one skipped", calls.Load())
+	}
+	select {
+	case got := <-posted:
+		t.Errorf("posted %q again although the header did not change", got)
+	default:
+	}
+}
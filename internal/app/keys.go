@@ -0,0 +1,138 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// keyAction is something the result list can do with the highlighted video
+// or with the list itself.
+type keyAction string
+
+const (
+	keyMenu     keyAction = "menu"
+	keyWatch    keyAction = "watch"
+	keyListen   keyAction = "listen"
+	keyDownload keyAction = "download"
+	keyQueue    keyAction = "queue"
+	keyLoadMore keyAction = "load_more"
+	keyDetails  keyAction = "details"
+	keyOpen     keyAction = "open"
+	keyCopyURL  keyAction = "copy_url"
+	keyChannel  keyAction = "channel"
+	keySort     keyAction = "sort"
+	keyFilter   keyAction = "filter"
+)
+
+// keysConfig is the [keys] table of the config file. Values are fzf key
+// names like "enter", "tab", "ctrl-s" or "alt-w", empty keeps the default.
+type keysConfig struct {
+	Menu     string `toml:"menu"`
+	Watch    string `toml:"watch"`
+	Listen   string `toml:"listen"`
+	Download string `toml:"download"`
+	Queue    string `toml:"queue"`
+	LoadMore string `toml:"load_more"`
+	Details  string `toml:"details"`
+	Open     string `toml:"open"`
+	CopyURL  string `toml:"copy_url"`
+	Channel  string `toml:"channel"`
+	Sort     string `toml:"sort"`
+	Filter   string `toml:"filter"`
+}
+
+// defaultKeys stay clear of the keys fzf uses for editing the query.
+var defaultKeys = keysConfig{
+	Menu:     "enter",
+	Watch:    "alt-w",
+	Listen:   "alt-l",
+	Download: "alt-d",
+	Queue:    "alt-q",
+	LoadMore: "tab",
+	Details:  "alt-i",
+	Open:     "alt-o",
+	CopyURL:  "alt-y",
+	Channel:  "alt-c",
+	Sort:     "ctrl-s",
+	Filter:   "ctrl-f",
+}
+
+// fzfKeyRegex matches the key names fzf accepts for --expect. Esc is left
+// out since it always goes back.
+var fzfKeyRegex = regexp.MustCompile(`^(ctrl-([a-z]|space|\\|\]|\^|/)|ctrl-alt-[a-z]|alt-([a-z0-9]|enter|space|bspace|up|down|left|right)|f([1-9]|1[0-2])|enter|tab|btab|del|up|down|left|right|home|end|pgup|pgdn|space|bspace|shift-(up|down|left|right|tab))$`)
+
+// keyMap resolves keys pressed in the result list to actions.
+type keyMap struct {
+	byKey    map[string]keyAction
+	byAction map[keyAction]string
+}
+
+func newKeyMap(c keysConfig) (*keyMap, error) {
+	k := &keyMap{byKey: make(map[string]keyAction), byAction: make(map[keyAction]string)}
+	for _, b := range []struct {
+		action          keyAction
+		value, fallback string
+	}{
+		{keyMenu, c.Menu, defaultKeys.Menu},
+		{keyWatch, c.Watch, defaultKeys.Watch},
+		{keyListen, c.Listen, defaultKeys.Listen},
+		{keyDownload, c.Download, defaultKeys.Download},
+		{keyQueue, c.Queue, defaultKeys.Queue},
+		{keyLoadMore, c.LoadMore, defaultKeys.LoadMore},
+		{keyDetails, c.Details, defaultKeys.Details},
+		{keyOpen, c.Open, defaultKeys.Open},
+		{keyCopyURL, c.CopyURL, defaultKeys.CopyURL},
+		{keyChannel, c.Channel, defaultKeys.Channel},
+		{keySort, c.Sort, defaultKeys.Sort},
+		{keyFilter, c.Filter, defaultKeys.Filter},
+	} {
+		key := strings.ToLower(strings.TrimSpace(b.value))
+		if key == "" {
+			key = b.fallback
+		}
+		if !fzfKeyRegex.MatchString(key) {
+			return nil, fmt.Errorf("keys: invalid key %q for %s", b.value, b.action)
+		}
+		if other, ok := k.byKey[key]; ok {
+			return nil, fmt.Errorf("keys: %q is bound to both %s and %s", key, other, b.action)
+		}
+		k.byKey[key] = b.action
+		k.byAction[b.action] = key
+	}
+	return k, nil
+}
+
+// expect lists the keys for fzf --expect.
+func (k *keyMap) expect() []string {
+	keys := make([]string, 0, len(k.byKey))
+	for key := range k.byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// action returns what key triggers. fzf reports an empty key for Enter
+// when it is not among the expected keys, which opens the action menu.
+func (k *keyMap) action(key string) keyAction {
+	if key == "" {
+		key = "enter"
+	}
+	if a, ok := k.byKey[key]; ok {
+		return a
+	}
+	return keyMenu
+}
+
+// label returns the key bound to a for display, e.g. "Ctrl-S".
+func (k *keyMap) label(a keyAction) string {
+	parts := strings.Split(k.byAction[a], "-")
+	for i, p := range parts {
+		if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "-")
+}
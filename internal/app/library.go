@@ -0,0 +1,245 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"gophertube/internal/services"
+	"gophertube/internal/types"
+)
+
+// Main menu entries.
+const (
+	menuSearchYouTube   = "Search YouTube"
+	menuSearchDownloads = "Search Downloads"
+	menuHistory         = "History"
+	menuCachedSearches  = "Cached Searches"
+	menuYouTubeOffline  = "Search YouTube (unavailable offline)"
+	menuPlayQueue       = "Play Queue"
+	menuPlayer          = "Player"
+
+	actionUnavailable = "Watch / Download / Listen (unavailable offline)"
+)
+
+// mediaExts are the file types listed in the downloads menu: the
+// containers videos are merged into, avi and the formats audio is
+// extracted to.
+var mediaExts = slices.Concat(extensions(containers), []string{".avi"}, extensions(audioFormats))
+
+// extensions turns the names of formats into file extensions.
+func extensions(formats []string) []string {
+	exts := make([]string, len(formats))
+	for i, f := range formats {
+		exts[i] = "." + f
+	}
+	return exts
+}
+
+// downloadItem is a media file in the downloads directory together with
+// the metadata yt-dlp wrote next to it, when present.
+type downloadItem struct {
+	File     string
+	Title    string   `json:"title"`
+	Channel  string   `json:"channel"`
+	Uploader string   `json:"uploader"`
+	Date     string   `json:"upload_date"`
+	Duration string   `json:"duration_string"`
+	Tags     []string `json:"tags"`
+}
+
+// Label is the searchable text shown for the item.
+func (d downloadItem) Label() string {
+	if d.Title == "" {
+		return d.File
+	}
+	parts := []string{d.Title}
+	if ch := firstNonEmpty(d.Channel, d.Uploader); ch != "" {
+		parts = append(parts, colorInfo+ch+colorReset)
+	}
+	if d.Duration != "" {
+		parts = append(parts, d.Duration)
+	}
+	if len(d.Date) == 8 {
+		parts = append(parts, d.Date[:4]+"-"+d.Date[4:6]+"-"+d.Date[6:])
+	}
+	if len(d.Tags) > 0 {
+		parts = append(parts, colorText+strings.Join(d.Tags, " ")+colorReset)
+	}
+	return strings.Join(parts, " · ")
+}
+
+func firstNonEmpty(ss ...string) string {
+	for _, s := range ss {
+		if s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+func isMediaFile(name string) bool {
+	for _, ext := range mediaExts {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// listDownloads returns the media files in dir with their metadata.
+func listDownloads(dir string) []downloadItem {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var items []downloadItem
+	for _, f := range files {
+		if f.IsDir() || !isMediaFile(f.Name()) {
+			continue
+		}
+		item := downloadItem{File: f.Name()}
+		base := strings.TrimSuffix(f.Name(), filepath.Ext(f.Name()))
+		if data, err := os.ReadFile(filepath.Join(dir, base+".info.json")); err == nil {
+			json.Unmarshal(data, &item)
+			item.File = f.Name()
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// findDownloaded returns the downloaded copy of the video titled title, or
+// an empty string. Downloads are named after the sanitized title.
+func findDownloaded(dir, title string) string {
+	base := sanitizeFilename(title)
+	for _, ext := range mediaExts {
+		p := filepath.Join(dir, base+ext)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// mainMenu returns the entries of the main menu and a header describing
+// what is missing while offline.
+func (s *session) mainMenu() ([]string, string) {
+	var menu, header []string
+	if s.activePlayer() != nil {
+		menu = append(menu, menuPlayer)
+		header = append(header, s.playerStatus())
+	}
+	if !s.offline {
+		if len(s.queue) > 0 {
+			menu = append(menu, menuPlayQueue)
+			header = append(header, fmt.Sprintf("%s%d queued%s • %s", colorSuccess, len(s.queue), colorReset, queueTitles(s.queue)))
+		}
+		menu = append(menu, menuSearchYouTube, menuSearchDownloads, menuHistory, menuCachedSearches)
+	} else {
+		menu = append(menu, menuSearchDownloads, menuHistory, menuCachedSearches, menuYouTubeOffline)
+		header = append(header, colorNotice+"Offline"+colorReset+" • searching, streaming and downloading are unavailable • downloads, history and cached searches still work")
+	}
+	return menu, strings.Join(header, "\n")
+}
+
+// queueTitles summarizes the queue for the main menu header.
+func queueTitles(queue []types.Video) string {
+	const shown = 3
+	titles := make([]string, 0, shown)
+	for _, v := range queue[:min(len(queue), shown)] {
+		titles = append(titles, v.Title)
+	}
+	s := strings.Join(titles, " • ")
+	if len(queue) > shown {
+		s += fmt.Sprintf(" • and %d more", len(queue)-shown)
+	}
+	return s
+}
+
+// historyMode lists previously watched videos.
+func (s *session) historyMode(ctx context.Context) {
+	entries, err := services.LoadHistory()
+	if err != nil || len(entries) == 0 {
+		s.ui.ShowMessage(colorError + "No watch history yet." + colorReset)
+		time.Sleep(600 * time.Millisecond)
+		return
+	}
+	videos := make([]types.Video, len(entries))
+	for i, e := range entries {
+		videos[i] = e.Video
+	}
+	s.browse(ctx, videos, "")
+}
+
+// cachedSearchesMode lists the searches kept in the search cache and shows
+// the results of the selected one.
+func (s *session) cachedSearchesMode(ctx context.Context) {
+	cache := services.Searches()
+	if cache == nil {
+		s.ui.ShowMessage(colorError + "The search cache is disabled." + colorReset)
+		time.Sleep(600 * time.Millisecond)
+		return
+	}
+	list, _ := cache.List()
+	if len(list) == 0 {
+		s.ui.ShowMessage(colorError + "No cached searches." + colorReset)
+		time.Sleep(600 * time.Millisecond)
+		return
+	}
+	lines := make([]string, len(list))
+	for i, c := range list {
+		lines[i] = fmt.Sprintf("%s\t%s%d results, %s%s", c.Query, colorText, c.Count, c.Fetched.Format("2006-01-02 15:04"), colorReset)
+	}
+	c, err := s.ui.ChooseOne(PickOptions{
+		Prompt:    "Cached searches: ",
+		Ansi:      true,
+		Delimiter: "\t",
+	}, lines)
+	if err != nil || c.Index < 0 {
+		return
+	}
+	s.searchAndBrowse(ctx, list[c.Index].Query)
+}
+
+// checkOnline updates the offline state unless it was forced on the
+// command line.
+func (s *session) checkOnline(ctx context.Context) {
+	if s.cmd.Bool(FlagOffline) {
+		s.offline = true
+	} else {
+		s.offline = !services.CheckConnectivity(ctx, 2*time.Second)
+	}
+	services.SetOffline(s.offline)
+}
+
+// recheckOnline picks the connection back up once it returns while
+// offline. The check runs in the background so that going back to the
+// menu never waits for it; its result shows the next time the menu does.
+func (s *session) recheckOnline(ctx context.Context) {
+	if !s.offline || s.cmd.Bool(FlagOffline) {
+		return
+	}
+	if s.probe != nil {
+		select {
+		case online := <-s.probe:
+			s.probe = nil
+			if online {
+				s.offline = false
+				services.SetOffline(false)
+				return
+			}
+		default:
+			return // still checking
+		}
+	}
+	s.probe = make(chan bool, 1)
+	go func(probe chan<- bool) {
+		probe <- services.CheckConnectivity(ctx, 2*time.Second)
+	}(s.probe)
+}
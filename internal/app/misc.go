@@ -1,13 +1,12 @@
 package app
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"gophertube/internal/services"
 	"gophertube/internal/types"
-	"io"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
@@ -15,45 +14,81 @@ import (
 )
 
 // buildSearchHeader creates the colored fzf header for the search UI.
-func buildSearchHeader(resultCount int, query string) string {
-	return fmt.Sprintf(
-		"--header=%s↑/↓%s to move • %stype%s to search • %sEnter%s to select • %sTab%s to load more • %s%d results • %s%s%s",
-		colorCyan, colorReset,
-		colorYellow, colorReset,
-		colorGreen, colorReset,
-		colorMagenta, colorReset,
-		colorWhite, resultCount,
-		colorMagenta, query, colorReset,
+// A non-empty status of the background player goes on its own line.
+func buildSearchHeader(shown, resultCount int, query string, view listView, keys *keyMap, status string) string {
+	header := fmt.Sprintf(
+		"%s↑/↓%s to move • %stype%s to search • %s%s%s to select • %s%s%s to load more • %s%s%s sort • %s%s%s filter • %s%d results • %s%s%s",
+		colorInfo, colorReset,
+		colorNotice, colorReset,
+		colorSuccess, keys.label(keyMenu), colorReset,
+		colorAccent, keys.label(keyLoadMore), colorReset,
+		colorInfo, keys.label(keySort), colorReset,
+		colorInfo, keys.label(keyFilter), colorReset,
+		colorText, resultCount,
+		colorAccent, query, colorReset,
 	)
+	var direct []string
+	for _, a := range []struct {
+		action keyAction
+		name   string
+	}{
+		{keyWatch, "watch"},
+		{keyListen, "listen"},
+		{keyDownload, "download"},
+		{keyQueue, "queue"},
+		{keyDetails, "details"},
+		{keyOpen, "open"},
+		{keyCopyURL, "copy URL"},
+		{keyChannel, "channel"},
+	} {
+		direct = append(direct, fmt.Sprintf("%s%s%s %s", colorSuccess, keys.label(a.action), colorReset, a.name))
+	}
+	header += "\n" + strings.Join(direct, " • ")
+	if status != "" {
+		header += "\n" + status
+	}
+	if view.Sort != sortRelevance || view.Filter != (listFilter{}) {
+		header += fmt.Sprintf("\n%sSort:%s %s", colorNotice, colorReset, view.Sort)
+		if f := view.Filter.String(); f != "" {
+			header += fmt.Sprintf(" • %sFilter:%s %s • %s%d of %d shown%s", colorNotice, colorReset, f, colorText, shown, resultCount, colorReset)
+		}
+	}
+	return header
 }
 
 // buildSearchPreview returns the shell for fzf --preview for search results.
-// It renders the thumbnail via chafa, pads to place the cursor below the image,
-// then prints colored metadata.
-func buildSearchPreview() string {
-	tpl := `sh -c 'thumbfile="$1"; title="$2"; w=$((FZF_PREVIEW_COLUMNS * %d / %d)); h=$((FZF_PREVIEW_LINES * %d / %d)); if [ -s "$thumbfile" ] && [ -f "$thumbfile" ]; then chafa --size=${w}x${h} "$thumbfile" 2>/dev/null; else echo "No image preview available"; fi; pad=$((FZF_PREVIEW_LINES - h - 1)); i=0; while [ $i -gt -1 ] && [ $i -lt $pad ]; do echo; i=$((i+1)); done; printf "%s%%s%s\n" "$title"; printf "%sDuration:%s %%s\n" "$3"; printf "%sPublished:%s %%s\n" "$4"; printf "%sAuthor:%s %%s\n" "$5"; printf "%sViews:%s %%s\n" "$6"' sh {3} {2} {4} {8} {5} {6}`
+// It renders the thumbnail via `gophertube preview-thumb`, pads to place the
+// cursor below the image, then prints colored metadata.
+func buildSearchPreview(thumbs thumbnailsConfig) string {
+	// Without thumbnails nothing is padded and the metadata starts at the top
+	image := "h=$FZF_PREVIEW_LINES;"
+	if thumbs.enabled() {
+		image = fmt.Sprintf(`if [ -s "$thumbfile" ] || [ -n "$8" ]; then "$7" preview-thumb%s --width=$w --height=$h --url="$8" "$thumbfile" 2>/dev/null; else echo "No image preview available"; fi;`, thumbs.protocolArg())
+	}
+	tpl := `sh -c 'thumbfile="$1"; title="$2"; w=$((FZF_PREVIEW_COLUMNS * %d / 100)); h=$((FZF_PREVIEW_LINES * %d / 100)); %s pad=$((FZF_PREVIEW_LINES - h - 1)); i=0; while [ $i -gt -1 ] && [ $i -lt $pad ]; do echo; i=$((i+1)); done; printf "%s%%s%s\n" "$title"; printf "%sDuration:%s %%s\n" "$3"; printf "%sPublished:%s %%s\n" "$4"; printf "%sAuthor:%s %%s\n" "$5"; printf "%sViews:%s %%s\n" "$6"' sh {3} {2} {4} {8} {5} {6} %s {9}`
 	return fmt.Sprintf(
 		tpl,
-		previewWidthNum, previewWidthDen,
-		previewHeightNum, previewHeightDen,
-		colorCyan, colorReset,
-		colorYellow, colorReset,
-		colorCyan, colorReset,
-		colorGreen, colorReset,
-		colorMagenta, colorReset,
+		previewWidth, previewHeight,
+		image,
+		colorInfo, colorReset,
+		colorNotice, colorReset,
+		colorInfo, colorReset,
+		colorSuccess, colorReset,
+		colorAccent, colorReset,
+		selfCommand(),
 	)
 }
 
 func printBanner() {
 	fmt.Print("\033[2J\033[H")
 	fmt.Println()
-	fmt.Println("    \033[1;33mGopherTube\033[0m")
-	fmt.Println("    \033[0;37mversion " + version + "\033[0m")
+	fmt.Println("    " + colorNotice + "GopherTube" + colorReset)
+	fmt.Println("    " + colorMuted + "version " + version + colorReset)
 	fmt.Println()
-	fmt.Println("    \033[1;36mFast Youtube Terminal UI\033[0m")
-	fmt.Println("    \033[0;37mPress Ctrl+C or Esc to exit\033[0m")
+	fmt.Println("    " + colorInfo + "Fast Youtube Terminal UI" + colorReset)
+	fmt.Println("    " + colorMuted + "Press Ctrl+C or Esc to exit" + colorReset)
 	fmt.Println()
-	fmt.Println("    \033[1;35m━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\033[0m")
+	fmt.Println("    " + barLine)
 	fmt.Println()
 }
 
@@ -62,16 +97,16 @@ func printProgressBar(current, total int) {
 	filled := (current * width) / total
 	percentage := (current * 100) / total
 
-	// Create animated progress bar with original cyan color
+	// Create animated progress bar in the theme's info color
 	bar := ""
 	for i := 0; i < width; i++ {
 		if i < filled {
-			bar += "\033[1;36m█" // Cyan for filled (original color)
+			bar += colorInfo + "█"
 		} else {
-			bar += "\033[0;37m░"
+			bar += colorMuted + "░"
 		}
 	}
-	bar += "\033[0m"
+	bar += colorReset
 
 	// Add spinning animation
 	spinners := []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
@@ -83,41 +118,83 @@ func printProgressBar(current, total int) {
 	fmt.Printf("\033[2K\r    %s %s %s", spinner, bar, percentStr)
 }
 
-func printSearchStats(videos []types.Video) {
+// searchStats summarizes a result list, one display line per entry.
+func searchStats(videos []types.Video) []string {
 	if len(videos) == 0 {
-		return
+		return nil
 	}
 
 	channels := make(map[string]int)
-	hasDuration := 0
+	var totalLength time.Duration
+	var totalViews int64
+	withLength, withViews := 0, 0
+	var oldest, newest time.Time
 
 	for _, v := range videos {
 		channels[v.Author]++
-		if v.Duration != "" {
-			hasDuration++
+		if v.Length > 0 {
+			totalLength += v.Length
+			withLength++
+		}
+		if v.Views != "" {
+			totalViews += v.ViewCount
+			withViews++
+		}
+		if !v.PublishedAt.IsZero() {
+			if oldest.IsZero() || v.PublishedAt.Before(oldest) {
+				oldest = v.PublishedAt
+			}
+			if v.PublishedAt.After(newest) {
+				newest = v.PublishedAt
+			}
 		}
 	}
 
-	// Calculate average duration if available
-	var avgDuration string
-	if hasDuration > 0 {
-		avgDuration = "~" + videos[0].Duration // Simple approximation
+	lines := []string{
+		colorInfo + "Search Statistics:" + colorReset,
+		fmt.Sprintf("%s• Total videos found: %s%d%s", colorMuted, colorSuccess, len(videos), colorReset),
+		fmt.Sprintf("%s• Unique channels: %s%d%s", colorMuted, colorNotice, len(channels), colorReset),
 	}
 
-	fmt.Println("    \033[1;36mSearch Statistics:\033[0m")
-	fmt.Printf("    \033[0;37m• Total videos found: \033[1;32m%d\033[0m\n", len(videos))
-	fmt.Printf("    \033[0;37m• Unique channels: \033[1;33m%d\033[0m\n", len(channels))
-
-	if avgDuration != "" {
-		fmt.Printf("    \033[0;37m• Average duration: \033[1;35m%s\033[0m\n", avgDuration)
+	if withLength > 0 {
+		avg := totalLength / time.Duration(withLength)
+		lines = append(lines, fmt.Sprintf("%s• Average duration: %s%s%s", colorMuted, colorAccent, formatDuration(avg), colorReset))
+	}
+	if withViews > 0 {
+		lines = append(lines, fmt.Sprintf("%s• Average views: %s%s%s", colorMuted, colorAccent, formatCount(totalViews/int64(withViews)), colorReset))
+	}
+	if !oldest.IsZero() && oldest != newest {
+		lines = append(lines, fmt.Sprintf("%s• Published: %s%s – %s%s", colorMuted, colorAccent, oldest.Format("Jan 2006"), newest.Format("Jan 2006"), colorReset))
 	}
 
 	// Show top channels if there are multiple
 	if len(channels) > 1 && len(videos) > 3 {
-		fmt.Printf("    \033[0;37m• Most active channel: \033[1;31m%s\033[0m\n", getTopChannel(channels))
+		lines = append(lines, fmt.Sprintf("%s• Most active channel: %s%s%s", colorMuted, colorError, getTopChannel(channels), colorReset))
 	}
 
-	fmt.Println()
+	return append(lines, "")
+}
+
+// formatDuration renders d the way YouTube does, e.g. 1:02:33 or 12:05.
+func formatDuration(d time.Duration) string {
+	secs := int64(d.Round(time.Second) / time.Second)
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+// formatCount abbreviates n, e.g. 1.2M or 15K.
+func formatCount(n int64) string {
+	switch {
+	case n >= 1e9:
+		return fmt.Sprintf("%.1fB", float64(n)/1e9)
+	case n >= 1e6:
+		return fmt.Sprintf("%.1fM", float64(n)/1e6)
+	case n >= 1e3:
+		return fmt.Sprintf("%.1fK", float64(n)/1e3)
+	}
+	return fmt.Sprintf("%d", n)
 }
 
 func getTopChannel(channels map[string]int) string {
@@ -137,20 +214,42 @@ func getTopChannel(channels map[string]int) string {
 	return topChannel
 }
 
-func printSearchTips() {
+// searchErrorMessage explains why a search failed and what to try next.
+func searchErrorMessage(err error) (msg, hint string) {
+	var se *services.StatusError
+	switch {
+	case errors.Is(err, services.ErrNoResults):
+		return "No results found.", "Try different or fewer keywords."
+	case errors.Is(err, services.ErrConsent):
+		return "YouTube is asking for cookie consent.", "Set cookies or cookies_from_browser in the config file."
+	case errors.Is(err, services.ErrCaptcha):
+		return "YouTube wants a captcha solved before searching again.", "Open youtube.com in a browser from this network, then use its cookies via cookies_from_browser."
+	case errors.Is(err, services.ErrRateLimited):
+		return "YouTube is rate limiting requests or asking for a captcha.", "Wait a few minutes, or use cookies from a logged-in browser."
+	case errors.Is(err, services.ErrLayoutChanged):
+		return "Could not read the YouTube results page.", "YouTube may have changed its layout. Please report it with the output of --debug."
+	case errors.Is(err, services.ErrNetwork):
+		return "Could not reach YouTube.", "Check your connection and the [network] proxy, or start with --offline."
+	case errors.As(err, &se):
+		return fmt.Sprintf("YouTube answered with HTTP %d.", se.Code), "Try again later."
+	}
+	return "Search failed: " + err.Error(), ""
+}
+
+func searchTip(keys *keyMap) string {
 	tips := []string{
-		"Tip: Press Tab to load more results, Esc to go back",
-		"Tip: Use ↑/↓ to navigate, Enter to select, Ctrl+C to exit",
+		"Tip: Press " + keys.label(keyLoadMore) + " to load more results, Esc to go back",
+		"Tip: Use ↑/↓ to navigate, " + keys.label(keyMenu) + " to select, Ctrl+C to exit",
+		"Tip: " + keys.label(keyWatch) + ", " + keys.label(keyListen) + " and " + keys.label(keyDownload) + " watch, listen and download without the menu",
 	}
 
 	randomTip := tips[time.Now().Unix()%int64(len(tips))]
-	fmt.Printf("    \033[1;33m%s\033[0m\n", randomTip)
-	fmt.Println()
+	return colorNotice + randomTip + colorReset
 }
 
-func readQuery() (string, bool) {
+func readQuery(prompt string) (string, bool) {
 	printBanner()
-	fmt.Print("    \033[1;32m>\033[0m ")
+	fmt.Print("    " + colorSuccess + prompt + colorReset)
 
 	// Use raw terminal mode for proper key detection
 	oldState, err := readline.MakeRaw(int(os.Stdin.Fd()))
@@ -197,70 +296,143 @@ func readQuery() (string, bool) {
 	return string(query), false
 }
 
-func runFzf(videos []types.Video, searchLimit int, query string) int {
-	limit := searchLimit
-	filter := ""
+// watchCancelKeys calls cancel when Esc or Ctrl+C is pressed until the
+// returned stop function is called.
+func (s *session) watchCancelKeys(cancel func()) (stop func()) {
+	return s.ui.WatchKeys(func(key string) bool {
+		// A lone Esc, not the start of an escape sequence
+		if key == "\x1b" || strings.Contains(key, "\x03") {
+			cancel()
+			return true
+		}
+		return false
+	})
+}
+
+// watchKeys passes what is typed to onKey, one read at a time so escape
+// sequences stay together, until onKey returns true or the returned stop
+// function is called. The terminal is read through its own descriptor with
+// a deadline, so stopping does not swallow the next key.
+func watchKeys(onKey func(key []byte) bool) (stop func()) {
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return func() {}
+	}
+	// SyscallConn keeps the file non-blocking, Fd would not
+	raw, err := tty.SyscallConn()
+	if err != nil || tty.SetReadDeadline(time.Now()) != nil {
+		tty.Close()
+		return func() {}
+	}
+	var state *readline.State
+	raw.Control(func(fd uintptr) {
+		state, err = readline.MakeRaw(int(fd))
+	})
+	if err != nil {
+		tty.Close()
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		buf := make([]byte, 16)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			tty.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+			n, err := tty.Read(buf)
+			if err != nil && !errors.Is(err, os.ErrDeadlineExceeded) {
+				return
+			}
+			if n > 0 && onKey(buf[:n]) {
+				return
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-finished
+		raw.Control(func(fd uintptr) {
+			readline.Restore(int(fd), state)
+		})
+		tty.Close()
+	}
+}
+
+// runFzf shows the result list and returns the (possibly extended) list
+// together with the selected index and the action its key is bound to, or
+// -2 when the user backed out. Sorting, filtering and loading more results
+// are handled here.
+func (s *session) runFzf(ctx context.Context, videos []types.Video, query string) ([]types.Video, int, keyAction) {
+	searchLimit := s.cmd.Int(FlagSearchLimit)
+	limit := max(searchLimit, len(videos))
 	for {
-		var input bytes.Buffer
-		for i, v := range videos {
+		shown := s.view.apply(videos)
+		lines := make([]string, len(shown))
+		for n, i := range shown {
+			v := videos[i]
 			thumbPath := v.ThumbnailPath
+			// Thumbnails still downloading have a known place in the cache,
+			// the preview fetches the URL itself if it gets there first.
+			thumbURL := ""
+			if thumbPath == "" && v.Thumbnail != "" {
+				thumbPath = services.Thumbs().Path(v.Thumbnail)
+				if !s.offline {
+					thumbURL = v.Thumbnail
+				}
+			}
 			thumbPath = strings.ReplaceAll(thumbPath, "'", "'\\''")
-			fmt.Fprintf(&input, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", i, v.Title, thumbPath, v.Duration, v.Author, v.Views, v.Description, v.Published)
-		}
-		fzfArgs := []string{
-			"--ansi",
-			"--with-nth=2..2",
-			"--delimiter=\t",
-			buildSearchHeader(len(videos), query),
-			"--expect=tab",
-			"--bind=esc:abort",
-			"--border=" + fzfBorder,
-			"--margin=" + fzfMargin,
-			"--preview-window=" + fzfPreviewWrap,
-			"--preview",
-			buildSearchPreview(),
+			lines[n] = fmt.Sprintf("%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s", i, v.Title, thumbPath, v.Duration, v.Author, v.Views, v.Description, v.Published, thumbURL)
 		}
-		if filter != "" {
-			fzfArgs = append(fzfArgs, "--query="+filter)
+		header := func() string {
+			return buildSearchHeader(len(shown), len(videos), query, s.view, s.keys, s.playerStatus())
 		}
-		cmd := exec.Command("fzf", fzfArgs...)
-		cmd.Stdin = &input
-		pr, pw, _ := os.Pipe()
-		cmd.Stdout = pw
-		cmd.Stderr = os.Stderr
-		if err := cmd.Start(); err != nil {
-			fmt.Println("\033[1;31mfzf error:\033[0m", err)
-			return -1
+		c, err := s.ui.ChooseOne(PickOptions{
+			Ansi:       true,
+			WithNth:    "2..2",
+			Delimiter:  "\t",
+			Header:     header(),
+			LiveHeader: s.whilePlaying(header),
+			Expect:     s.keys.expect(),
+			Preview:    buildSearchPreview(s.config.Thumbnails),
+		}, lines)
+		if err != nil {
+			return videos, -2, "" // user pressed escape in fzf
 		}
-		pw.Close()
-		selected, _ := io.ReadAll(pr)
-		cmd.Wait()
-		lines := strings.Split(strings.TrimSpace(string(selected)), "\n")
-		if len(lines) == 0 || (len(lines) == 1 && lines[0] == "") {
-			return -2 // user pressed escape in fzf
-		}
-		if lines[0] == "tab" {
-			fmt.Printf("    \033[1;35mLoading more results...\033[0m\n")
+		action := s.keys.action(c.Key)
+		switch action {
+		case keySort:
+			s.view.Sort = s.view.Sort.next()
+			continue
+		case keyFilter:
+			s.view.Filter = s.filterMenu(s.view.Filter, videos)
+			continue
+		case keyLoadMore:
+			if query == "" {
+				continue // nothing to load more of
+			}
+			s.ui.ShowMessage(colorAccent + "Loading more results..." + colorReset)
 			limit += searchLimit
-			moreVideos, err := services.SearchYouTube(query, limit, func(current, total int) {
-				// progressCurrent = current // This line was removed
-				// progressTotal = total // This line was removed
-			})
+			moreVideos, err := s.fetch(ctx, query, limit, nil)
 			if err != nil || len(moreVideos) == len(videos) {
 				continue
 			}
 			videos = moreVideos
-			fmt.Printf("    \033[1;32mLoaded %d total results!\033[0m\n", len(videos))
-			printSearchStats(videos)
+			s.ui.ShowMessage(fmt.Sprintf("%sLoaded %d total results!%s", colorSuccess, len(videos), colorReset))
+			for _, line := range searchStats(videos) {
+				s.ui.ShowMessage(line)
+			}
 			continue
 		}
-		line := lines[0]
-		if line == "" {
-			return -1
+		if c.Index < 0 {
+			continue
 		}
-		idxStr := strings.SplitN(line, "\t", 2)[0]
-		idx := 0
-		fmt.Sscanf(idxStr, "%d", &idx)
-		return idx
+		return videos, shown[c.Index], action
 	}
 }
@@ -1,12 +1,17 @@
 package app
 
 import (
+    "context"
+    "errors"
     "fmt"
+    "gophertube/internal/player"
     "gophertube/internal/services"
+    "gophertube/internal/types"
     "os"
     "os/exec"
     "path/filepath"
     "strings"
+    "syscall"
     "time"
 
     "github.com/urfave/cli/v3"
@@ -26,22 +31,10 @@ func sanitizeFilename(s string) string {
     }, s)
 }
 
-// qualityToFormat maps a human-readable quality to yt-dlp/mpv format selectors.
-func qualityToFormat(q string) string {
-    switch q {
-    case "1080p":
-        return "bestvideo[height<=1080]+bestaudio/best[height<=1080]"
-    case "720p":
-        return "bestvideo[height<=720]+bestaudio/best[height<=720]"
-    case "480p":
-        return "bestvideo[height<=480]+bestaudio/best[height<=480]"
-    case "360p":
-        return "bestvideo[height<=360]+bestaudio/best[height<=360]"
-    case "Audio":
-        return "bestaudio"
-    default:
-        return "best"
-    }
+// isAudioFormat reports whether the yt-dlp format selector f picks audio
+// only, e.g. "bestaudio" or "bestaudio[ext=m4a]/bestaudio".
+func isAudioFormat(f string) bool {
+    return strings.HasPrefix(f, "bestaudio") && !strings.Contains(f, "+")
 }
 
 // hasFFmpeg checks if ffmpeg is available for merging video/audio.
@@ -71,9 +64,13 @@ func expandPath(p string) string {
 }
 
 // buildDownloadsPreview returns the fzf preview command for the downloads list.
-func buildDownloadsPreview(downloadsPath string) string {
-    const tpl = `sh -c 'file="$1"; base="%s/${file%%%%.*}"; thumb="$base.jpg"; w=$((FZF_PREVIEW_COLUMNS * 9 / 10)); h=$((FZF_PREVIEW_LINES * 3 / 5)); if [ -f "$thumb" ]; then chafa --size=${w}x${h} "$thumb" 2>/dev/null; else echo "No image preview available"; fi; echo; printf "\033[1;36m%%s\033[0m\n" "$file"' sh {}`
-    return fmt.Sprintf(tpl, downloadsPath)
+func buildDownloadsPreview(downloadsPath string, thumbs thumbnailsConfig) string {
+    image := ""
+    if thumbs.enabled() {
+        image = fmt.Sprintf(`if [ -f "$thumb" ]; then "$2" preview-thumb%s --width=$w --height=$h "$thumb" 2>/dev/null; else echo "No image preview available"; fi; echo;`, thumbs.protocolArg())
+    }
+    const tpl = `sh -c 'file="$1"; base="%s/${file%%%%.*}"; thumb="$base.jpg"; w=$((FZF_PREVIEW_COLUMNS * %d / 100)); h=$((FZF_PREVIEW_LINES * %d / 100)); %s printf "%s%%s%s\n" "$file"' sh {1} %s`
+    return fmt.Sprintf(tpl, downloadsPath, previewWidth, previewHeight, image, colorInfo, colorReset, selfCommand())
 }
 
 // MediaPlayer represents available media players
@@ -82,10 +79,10 @@ type MediaPlayer struct {
     Path string
 }
 
-// checkAvailablePlayer checks for MPV.
-func checkAvailablePlayer() *MediaPlayer {
+// checkAvailablePlayer checks for MPV at the configured path.
+func checkAvailablePlayer(mpv string) *MediaPlayer {
     // Prefer MPV for better performance and terminal integration
-    if path, err := exec.LookPath("mpv"); err == nil {
+    if path, err := exec.LookPath(mpv); err == nil {
         return &MediaPlayer{
             Name: "mpv",
             Path: path,
@@ -94,248 +91,386 @@ func checkAvailablePlayer() *MediaPlayer {
     return nil
 }
 
-// playWithPlayer plays media using the detected player.
-func playWithPlayer(player *MediaPlayer, url string, isAudioOnly bool) error {
-    var args []string
+// command prepares a child process that is asked to quit with SIGTERM, and
+// killed if it does not, once ctx is canceled.
+func command(ctx context.Context, name string, args ...string) *exec.Cmd {
+    cmd := exec.CommandContext(ctx, name, args...)
+    cmd.Cancel = func() error {
+        return cmd.Process.Signal(syscall.SIGTERM)
+    }
+    cmd.WaitDelay = 3 * time.Second
+    return cmd
+}
 
-    if isAudioOnly {
-        args = []string{"--no-video"}
+// mpvArgs are the arguments every mpv run starts with: how to reach YouTube,
+// the yt-dlp to resolve videos with and the configured extra arguments.
+func (s *session) mpvArgs() []string {
+    args := services.MpvArgs()
+    if ytdlp := s.config.Ytdlp.withDefaults().Path; ytdlp != defaultYtdlp.Path {
+        args = append(args, "--script-opts-append=ytdl_hook-ytdl_path="+ytdlp)
     }
+    return append(args, s.config.Player.Args...)
+}
 
-    args = append(args, url)
+// session holds what the interactive modes need. Everything that talks to
+// the user goes through ui so the flows can run against a scripted UI.
+type session struct {
+    cmd     *cli.Command
+    ui      UI
+    search  func(ctx context.Context, query string, limit int, progress func(current, total int)) ([]types.Video, error)
+    offline bool
+    probe   chan bool // connectivity check running while offline, nil when none is
+    view    listView  // sort and filter of the result list being browsed
+
+    config    fileConfig
+    blocklist *blocklist
+    keys      *keyMap
+    queue     []types.Video // queued from the result lists, played as audio, saved across restarts
+
+    player  *player.Player // background audio, nil when nothing plays
+    playing []types.Video  // the playlist of player
+}
 
-    cmd := exec.Command(player.Path, args...)
-    cmd.Stdout = os.Stdout
-    cmd.Stderr = os.Stderr
+func newSession(cmd *cli.Command, ui UI, config fileConfig) (*session, error) {
+    bl, err := newBlocklist(config.Blocklist)
+    if err != nil {
+        return nil, err
+    }
+    keys, err := newKeyMap(config.Keys)
+    if err != nil {
+        return nil, err
+    }
+    // A queue that cannot be read is not worth refusing to start over
+    queue, _ := services.LoadQueue()
+    return &session{
+        cmd:       cmd,
+        ui:        ui,
+        search:    services.SearchYouTube,
+        config:    config,
+        blocklist: bl,
+        keys:      keys,
+        queue:     queue,
+    }, nil
+}
 
-    return cmd.Run()
+// fetch runs a search and drops blocked videos from the results.
+func (s *session) fetch(ctx context.Context, query string, limit int, progress func(current, total int)) ([]types.Video, error) {
+    videos, err := s.search(ctx, query, limit, progress)
+    return s.blocklist.filter(videos), err
 }
 
-func gophertubeYouTubeMode(cmd *cli.Command) {
-    query, esc := readQuery()
-    if esc || query == "" {
-        fmt.Print("\033[2J\033[H")
+func (s *session) youTubeMode(ctx context.Context) {
+    query, err := s.ui.PromptText("> ")
+    if err != nil {
         return
     }
-    for {
-        // Spinner/progress state
-        progressCurrent := 0
-        progressTotal := 1
-        progressDone := make(chan struct{})
-
-        // Start spinner goroutine
-        go func() {
-            for {
-                select {
-                case <-progressDone:
-                    return
-                default:
-                    printProgressBar(progressCurrent, progressTotal)
-                    time.Sleep(100 * time.Millisecond)
-                }
-            }
-        }()
-
-        videos, err := services.SearchYouTube(query, cmd.Int(FlagSearchLimit), func(current, total int) {
-            progressCurrent = current
-            progressTotal = total
-        })
-
-        close(progressDone)
-        fmt.Print("\033[2K\r\n") // Clear progress bar/spinner line
-        fmt.Println()
-        fmt.Println()
-
-        if err != nil || len(videos) == 0 {
-            fmt.Println("    "+colorRed+"No results found."+colorReset)
-            fmt.Println()
-            fmt.Println("    "+colorWhite+"Press any key to search again..."+colorReset)
-            os.Stdin.Read(make([]byte, 1))
-            return
-        }
+    s.searchAndBrowse(ctx, query)
+}
 
-        fmt.Printf("    %sFound %d results!%s\n", colorGreen, len(videos), colorReset)
-        printSearchStats(videos)
-        printSearchTips()
-        // Reduced delay for faster response
-        time.Sleep(200 * time.Millisecond)
+// searchAndBrowse runs query with a progress bar and shows the results.
+func (s *session) searchAndBrowse(ctx context.Context, query string) {
+    // Spinner/progress state
+    progressCurrent := 0
+    progressTotal := 1
+    progressDone := make(chan struct{})
 
+    // Start spinner goroutine
+    go func() {
         for {
-            selected := runFzf(videos, cmd.Int(FlagSearchLimit), query)
-            if selected == -2 {
-                // User pressed escape, go back to new search
+            select {
+            case <-progressDone:
                 return
+            default:
+                s.ui.ShowProgress(progressCurrent, progressTotal)
+                time.Sleep(100 * time.Millisecond)
             }
-            if selected < 0 || selected >= len(videos) {
-                continue // Stay in the same list
-            }
+        }
+    }()
+
+    // Esc or Ctrl+C cancel the search and the thumbnail downloads
+    searchCtx, cancel := context.WithCancel(ctx)
+    defer cancel()
+    stopKeys := s.watchCancelKeys(cancel)
+    videos, err := s.fetch(searchCtx, query, s.cmd.Int(FlagSearchLimit), func(current, total int) {
+        progressCurrent = current
+        progressTotal = total
+    })
+    stopKeys()
+
+    close(progressDone)
+    s.ui.ShowMessage("\033[2K\r")
+    s.ui.ShowMessage("")
+
+    if ctx.Err() != nil {
+        return
+    }
+    if errors.Is(err, context.Canceled) {
+        s.ui.ShowMessage(colorNotice + "Search canceled." + colorReset)
+        time.Sleep(600 * time.Millisecond)
+        return
+    }
 
-            // Show Watch/Download/Audio menu
-            menu := []string{"Watch", "Download", "Listen"}
-            action := exec.Command("fzf", "--prompt=Action: ")
-            action.Stdin = strings.NewReader(strings.Join(menu, "\n"))
-            out, errAct := action.Output()
-            choice := strings.TrimSpace(string(out))
-            if errAct != nil || choice == "" {
-                // ESC/cancel -> back to results list
-                continue
-            }
+    if errors.Is(err, services.ErrOffline) {
+        s.ui.ShowMessage(colorNotice + "Offline: no cached results for '" + query + "'." + colorReset)
+        s.ui.ShowMessage("")
+        s.ui.Pause("Press any key to return...")
+        return
+    }
+    if err == nil && len(videos) == 0 {
+        err = services.ErrNoResults
+    }
+    if err != nil {
+        msg, hint := searchErrorMessage(err)
+        s.ui.ShowMessage(colorError + msg + colorReset)
+        if hint != "" {
+            s.ui.ShowMessage(colorText + hint + colorReset)
+        }
+        if dump := services.LastDump(); dump != "" {
+            s.ui.ShowMessage(colorText + "Raw response saved to " + dump + colorReset)
+        }
+        s.ui.ShowMessage("")
+        s.ui.Pause("Press any key to search again...")
+        return
+    }
 
-            if choice == "Download" {
-                qualities := []string{"1080p", "720p", "480p", "360p", "Audio"}
-                actionQ := exec.Command("fzf", "--prompt=Quality: ")
-                actionQ.Stdin = strings.NewReader(strings.Join(qualities, "\n"))
-                outQ, errQ := actionQ.Output()
-                selectedQ := strings.TrimSpace(string(outQ))
-                if errQ != nil || selectedQ == "" {
-                    // ESC/cancel -> back to results list
-                    continue
-                }
-
-                // Map quality to yt-dlp format
-                format := qualityToFormat(selectedQ)
-                dlPath := expandPath(cmd.String(FlagDownloadsPath))
-                os.MkdirAll(dlPath, 0755)
-                // Sanitize filename
-                filename := sanitizeFilename(videos[selected].Title)
-                outputPath := fmt.Sprintf("%s/%s.%%(ext)s", dlPath, filename)
-                fmt.Printf("    %sDownloading '%s' as %s...%s\n", colorGreen, videos[selected].Title, selectedQ, colorReset)
-
-                ytDlpArgs := []string{"-f", format, "-o", outputPath, "--write-info-json", "--write-thumbnail", "--convert-thumbnails", "jpg", videos[selected].URL}
-
-                // override the default args with an audio only version.
-                // Note: this downloads it as a .webm, then converts it to a .opus file.
-                if format == "bestaudio" {
-                    ytDlpArgs = []string{"-x", "-f", format, "-o", outputPath, "--write-info-json", "--write-thumbnail", "--convert-thumbnails", "jpg", videos[selected].URL}
-                } else {
-                    // For video+audio, ensure merge to mp4 when possible
-                    // Warn if ffmpeg is missing (yt-dlp needs it to merge)
-                    if !hasFFmpeg() {
-                        fmt.Println("    "+colorYellow+"Warning: ffmpeg not found. Install ffmpeg to merge video+audio properly."+colorReset)
-                        fmt.Println("    "+colorWhite+"On Ubuntu: sudo apt install ffmpeg | macOS: brew install ffmpeg | Arch: pacman -S ffmpeg"+colorReset)
-                    }
-                    ytDlpArgs = append([]string{"-f", format}, append([]string{"-o", outputPath, "--merge-output-format", "mp4", "--write-info-json", "--write-thumbnail", "--convert-thumbnails", "jpg"}, videos[selected].URL)...) 
-                }
-                actionDl := exec.Command("yt-dlp", ytDlpArgs...)
-                actionDl.Stdout = os.Stdout
-                actionDl.Stderr = os.Stderr
-                err := actionDl.Run()
-                if err == nil {
-                    fmt.Printf("    %sDownload complete!%s\n", colorGreen, colorReset)
-                    fmt.Printf("    %sSaved to: %s%s\n", colorWhite, dlPath, colorReset)
-                } else {
-                    fmt.Printf("    %sDownload failed!%s\n", colorRed, colorReset)
-                }
-                fmt.Println("    "+colorWhite+"Press any key to return..."+colorReset)
-                os.Stdin.Read(make([]byte, 1))
-                // After handling download, return to results list
-                continue
-            }
+    s.ui.ShowMessage(fmt.Sprintf("%sFound %d results!%s", colorSuccess, len(videos), colorReset))
+    for _, line := range searchStats(videos) {
+        s.ui.ShowMessage(line)
+    }
+    s.ui.ShowMessage(searchTip(s.keys))
+    s.ui.ShowMessage("")
+    // Reduced delay for faster response
+    time.Sleep(200 * time.Millisecond)
 
-            // New Audio playback logic
-            if choice == "Listen" {
-                player := checkAvailablePlayer()
-                if player == nil {
-                    fmt.Println("    "+colorRed+"No media player found!"+colorReset)
-                    fmt.Println("    "+colorWhite+"Please install MPV to play audio."+colorReset)
-                    fmt.Println("    "+colorYellow+"Install MPV: sudo apt install mpv (Ubuntu) | brew install mpv (macOS)"+colorReset)
-                    fmt.Println("    "+colorWhite+"Press any key to return..."+colorReset)
-                    os.Stdin.Read(make([]byte, 1))
-                    continue // Go back to the search results
-                }
-
-                fmt.Printf("    %sPlaying Audio with %s: %s%s\n", colorYellow, strings.ToUpper(player.Name), videos[selected].Title, colorReset)
-                fmt.Printf("    %sChannel: %s%s\n", colorWhite, videos[selected].Author, colorReset)
-                fmt.Printf("    %sDuration: %s%s\n", colorWhite, videos[selected].Duration, colorReset)
-                fmt.Printf("    %sPublished: %s%s\n", colorCyan, videos[selected].Published, colorReset)
-                fmt.Println("    "+barMagenta)
-                fmt.Println("    "+colorYellow+"Controls: 'q' to quit, SPACE to pause/resume, ←→ to seek"+colorReset)
-                fmt.Println("    "+barMagenta)
-                fmt.Println()
-
-                // Extract direct audio stream URL
-                audioCmd := exec.Command("yt-dlp", "-f", "bestaudio[ext=m4a]/bestaudio", "-g", videos[selected].URL)
-                streamURLBytes, err := audioCmd.Output()
-                if err != nil {
-                    fmt.Println("    "+colorRed+"Failed to get direct audio URL."+colorReset)
-                    fmt.Println("    "+colorWhite+"Make sure yt-dlp is installed."+colorReset)
-                    fmt.Println("    "+colorWhite+"Press any key to return..."+colorReset)
-                    os.Stdin.Read(make([]byte, 1))
-                    continue // Go back to the search results
-                }
-                streamURL := strings.TrimSpace(string(streamURLBytes))
-
-                if err := playWithPlayer(player, streamURL, true); err != nil {
-                    fmt.Printf("    \033[1;31mFailed to play audio with %s.\033[0m\n", player.Name)
-                }
-
-                fmt.Println("    "+colorWhite+"Press Enter to return."+colorReset)
-                os.Stdin.Read(make([]byte, 1))
-                continue // Return to the search results
-            }
+    s.browse(ctx, videos, query)
+}
 
-            // Watch as before
-            fmt.Printf("    %sPlaying: %s%s\n", colorYellow, videos[selected].Title, colorReset)
-            fmt.Printf("    %sChannel: %s%s\n", colorWhite, videos[selected].Author, colorReset)
-            fmt.Printf("    %sDuration: %s%s\n", colorWhite, videos[selected].Duration, colorReset)
-            fmt.Printf("    %sPublished: %s%s\n", colorCyan, videos[selected].Published, colorReset)
-            fmt.Println()
-            fmt.Println("    "+barMagenta)
-            fmt.Println()
-            mpvPath := "mpv"
-            quality := cmd.String(FlagQuality)
-            var mpvArgs []string
-
-            // Add the fullscreen flag for video playback
-            mpvArgs = append(mpvArgs, "--fs")
-
-            if quality != "" {
-                f := qualityToFormat(quality)
-                if f == "bestaudio" {
-                    mpvArgs = append(mpvArgs, "--no-video")
-                }
-                mpvArgs = append(mpvArgs, "--ytdl-format="+f)
-            }
+// browse shows a result list until the user backs out, running the action
+// bound to the key each video was selected with.
+func (s *session) browse(ctx context.Context, videos []types.Video, query string) {
+    s.view = listView{}
+    for {
+        videos = s.blocklist.filter(videos)
+        var selected int
+        var action keyAction
+        videos, selected, action = s.runFzf(ctx, videos, query)
+        if selected == -2 {
+            // User pressed escape, go back to new search
+            return
+        }
+        if selected < 0 || selected >= len(videos) {
+            continue // Stay in the same list
+        }
+        s.runAction(ctx, action, videos[selected])
+    }
+}
 
-            mpvArgs = append(mpvArgs, videos[selected].URL)
-            exec.Command(mpvPath, mpvArgs...).Run()
-            continue
+// videoAction asks what to do with video and does it. Offline, only a
+// downloaded copy can be played and the other actions are listed as such.
+func (s *session) videoAction(ctx context.Context, video types.Video) {
+    local := findDownloaded(expandPath(s.cmd.String(FlagDownloadsPath)), video.Title)
+
+    menu := []string{"Watch", "Download", "Listen", menuChooseStream}
+    if s.offline {
+        menu = []string{actionUnavailable}
+    }
+    menu = append(menu, "Block Channel")
+    if local != "" {
+        menu = append([]string{"Play Downloaded"}, menu...)
+    }
+
+    // Show Watch/Download/Audio menu
+    choice, err := chooseString(s.ui, "Action: ", menu)
+    if err != nil {
+        // ESC/cancel -> back to results list
+        return
+    }
+
+    switch choice {
+    case "Play Downloaded":
+        s.playFile(ctx, local)
+    case "Block Channel":
+        if err := s.blockChannel(video); err != nil {
+            s.ui.ShowMessage(colorError + "Could not update the blocklist: " + err.Error() + colorReset)
+            s.ui.Pause("Press any key to return...")
         }
+    case actionUnavailable:
+        s.showUnavailable()
+    case "Download":
+        s.download(ctx, video)
+    case "Listen":
+        s.listen(ctx, video)
+    case menuChooseStream:
+        s.chooseStream(ctx, video)
+    default:
+        s.watch(ctx, video)
     }
 }
 
-func gophertubeDownloadsMode(cmd *cli.Command) {
-    dlPath := expandPath(cmd.String(FlagDownloadsPath))
-    files, err := os.ReadDir(dlPath)
-    if err != nil || len(files) == 0 {
-        fmt.Println("    "+colorRed+"No downloaded videos found."+colorReset)
-        time.Sleep(600 * time.Millisecond)
+func (s *session) download(ctx context.Context, video types.Video) {
+    ytdlp := s.config.Ytdlp.withDefaults()
+    selectedQ, err := chooseString(s.ui, "Quality: ", append(ytdlp.DownloadQualities, menuChooseStream))
+    if err != nil {
+        // ESC/cancel -> back to results list
         return
     }
-    var videoFiles []string
-    for _, f := range files {
-        if !f.IsDir() && (strings.HasSuffix(f.Name(), ".mp4") || strings.HasSuffix(f.Name(), ".mkv") || strings.HasSuffix(f.Name(), ".webm") || strings.HasSuffix(f.Name(), ".avi") || strings.HasSuffix(f.Name(), ".m4a") || strings.HasSuffix(f.Name(), ".mp3") || strings.HasSuffix(f.Name(), ".opus")) {
-            videoFiles = append(videoFiles, f.Name())
+    if selectedQ == menuChooseStream {
+        if f, ok := s.pickStream(ctx, video); ok {
+            s.downloadFormat(ctx, video, streamLabel(f), streamFormat(f), !f.HasVideo())
         }
+        return
+    }
+
+    // Map quality to yt-dlp format
+    format := ytdlp.format(selectedQ)
+    s.downloadFormat(ctx, video, selectedQ, format, isAudioFormat(format))
+}
+
+// downloadFormat downloads video in the yt-dlp format, described to the
+// user as label. Audio-only formats are extracted to an audio file.
+func (s *session) downloadFormat(ctx context.Context, video types.Video, label, format string, audioOnly bool) {
+    ytdlp := s.config.Ytdlp.withDefaults()
+    dlPath := expandPath(s.cmd.String(FlagDownloadsPath))
+    os.MkdirAll(dlPath, 0755)
+    // Sanitize filename
+    filename := sanitizeFilename(video.Title)
+    outputPath := fmt.Sprintf("%s/%s.%%(ext)s", dlPath, filename)
+    s.ui.ShowMessage(fmt.Sprintf("%sDownloading '%s' as %s...%s", colorSuccess, video.Title, label, colorReset))
+
+    ytDlpArgs := []string{"-f", format, "-o", outputPath, "--write-info-json", "--write-thumbnail", "--convert-thumbnails", "jpg", video.URL}
+
+    // override the default args with an audio only version.
+    // Note: yt-dlp converts it to the [audio] format, .opus when none is set.
+    if audioOnly {
+        ytDlpArgs = append(s.config.Audio.args(), "-f", format, "-o", outputPath, "--write-info-json", "--write-thumbnail", "--convert-thumbnails", "jpg", video.URL)
+    } else {
+        // For video+audio, ensure merge to the container (mp4 by default) when possible
+        // Warn if ffmpeg is missing (yt-dlp needs it to merge)
+        if !hasFFmpeg() {
+            s.ui.ShowMessage(colorNotice + "Warning: ffmpeg not found. Install ffmpeg to merge video+audio properly." + colorReset)
+            s.ui.ShowMessage(colorText + "On Ubuntu: sudo apt install ffmpeg | macOS: brew install ffmpeg | Arch: pacman -S ffmpeg" + colorReset)
+        }
+        ytDlpArgs = append([]string{"-f", format}, append([]string{"-o", outputPath, "--merge-output-format", ytdlp.mergeFormat(), "--write-info-json", "--write-thumbnail", "--convert-thumbnails", "jpg"}, video.URL)...)
+    }
+    args := append(append(services.YtDlpArgs(), ytdlp.Args...), ytDlpArgs...)
+    actionDl := command(ctx, ytdlp.Path, args...)
+    actionDl.Stdout = os.Stdout
+    actionDl.Stderr = os.Stderr
+    if err := actionDl.Run(); err == nil {
+        s.ui.ShowMessage(fmt.Sprintf("%sDownload complete!%s", colorSuccess, colorReset))
+        s.ui.ShowMessage(fmt.Sprintf("%sSaved to: %s%s", colorText, dlPath, colorReset))
+    } else {
+        s.ui.ShowMessage(fmt.Sprintf("%sDownload failed!%s", colorError, colorReset))
+    }
+    s.ui.Pause("Press any key to return...")
+}
+
+func (s *session) listen(ctx context.Context, video types.Video) {
+    s.playAudio(ctx, []types.Video{video})
+}
+
+// playAudio plays videos as audio one after the other in the background,
+// replacing what played before, and opens the now-playing screen.
+func (s *session) playAudio(ctx context.Context, videos []types.Video) {
+    if checkAvailablePlayer(s.config.Player.withDefaults().Path) == nil {
+        s.ui.ShowMessage(colorError + "No media player found!" + colorReset)
+        s.ui.ShowMessage(colorText + "Please install MPV to play audio." + colorReset)
+        s.ui.ShowMessage(colorNotice + "Install MPV: sudo apt install mpv (Ubuntu) | brew install mpv (macOS)" + colorReset)
+        s.ui.Pause("Press any key to return...")
+        return
+    }
+
+    s.stopPlayer()
+    p, err := s.startAudio(ctx, videos)
+    if err != nil {
+        s.ui.ShowMessage(colorError + "Failed to start mpv: " + err.Error() + colorReset)
+        s.ui.Pause("Press any key to return...")
+        return
+    }
+    s.player, s.playing = p, videos
+    s.nowPlaying(ctx)
+}
+
+func (s *session) watch(ctx context.Context, video types.Video) {
+    quality := s.cmd.String(FlagQuality)
+    if quality == "" {
+        s.watchFormat(ctx, video, "", false)
+        return
     }
-    if len(videoFiles) == 0 {
-        fmt.Println("    "+colorRed+"No downloaded videos found."+colorReset)
+    f := s.config.Ytdlp.format(quality)
+    s.watchFormat(ctx, video, f, isAudioFormat(f))
+}
+
+// watchFormat plays video with mpv in the yt-dlp format, or the one mpv
+// picks when format is empty.
+func (s *session) watchFormat(ctx context.Context, video types.Video, format string, audioOnly bool) {
+    s.ui.ShowMessage(fmt.Sprintf("%sPlaying: %s%s", colorNotice, video.Title, colorReset))
+    s.showVideoInfo(video)
+    s.ui.ShowMessage("")
+    s.ui.ShowMessage(barLine)
+    s.ui.ShowMessage("")
+    cfg := s.config.Player.withDefaults()
+    mpvArgs := s.mpvArgs()
+
+    // Add the video arguments, fullscreen by default
+    mpvArgs = append(mpvArgs, cfg.VideoArgs...)
+
+    if audioOnly {
+        mpvArgs = append(mpvArgs, "--no-video")
+    }
+    if format != "" {
+        mpvArgs = append(mpvArgs, "--ytdl-format="+format)
+    }
+
+    mpvArgs = append(mpvArgs, video.URL)
+    if p := s.activePlayer(); p != nil {
+        p.SetPaused(true)
+    }
+    services.AppendHistory(video)
+    command(ctx, cfg.Path, mpvArgs...).Run()
+}
+
+func (s *session) showVideoInfo(video types.Video) {
+    s.ui.ShowMessage(fmt.Sprintf("%sChannel: %s%s", colorText, video.Author, colorReset))
+    s.ui.ShowMessage(fmt.Sprintf("%sDuration: %s%s", colorText, video.Duration, colorReset))
+    s.ui.ShowMessage(fmt.Sprintf("%sPublished: %s%s", colorInfo, video.Published, colorReset))
+}
+
+func (s *session) downloadsMode(ctx context.Context) {
+    dlPath := expandPath(s.cmd.String(FlagDownloadsPath))
+    items := listDownloads(dlPath)
+    if len(items) == 0 {
+        s.ui.ShowMessage(colorError + "No downloaded videos found." + colorReset)
         time.Sleep(600 * time.Millisecond)
         return
     }
-    fzfPreview := buildDownloadsPreview(dlPath)
-    action := exec.Command("fzf", "--ansi", "--preview-window=wrap", "--prompt=Downloads: ", "--preview", fzfPreview)
-    action.Stdin = strings.NewReader(strings.Join(videoFiles, "\n"))
-    out, _ := action.Output()
-    selected := strings.TrimSpace(string(out))
-    if selected == "" {
+    // The file name is a hidden first field, the rest is the metadata
+    // yt-dlp saved next to it so it can be searched too.
+    lines := make([]string, len(items))
+    for i, d := range items {
+        lines[i] = d.File + "\t" + d.Label()
+    }
+    c, err := s.ui.ChooseOne(PickOptions{
+        Prompt:    "Downloads: ",
+        Ansi:      true,
+        Delimiter: "\t",
+        WithNth:   "2..",
+        Preview:   buildDownloadsPreview(dlPath, s.config.Thumbnails),
+    }, lines)
+    if err != nil || c.Index < 0 {
         return
     }
-    filePath := filepath.Join(dlPath, selected)
-    fmt.Printf("    %sPlaying: %s%s\n", colorYellow, selected, colorReset)
-    fmt.Println()
-    fmt.Println("    "+barMagenta)
-    fmt.Println()
-    mpvPath := "mpv"
-    exec.Command(mpvPath, filePath).Run()
-}
\ No newline at end of file
+    s.playFile(ctx, filepath.Join(dlPath, items[c.Index].File))
+}
+
+// playFile plays a local media file with mpv.
+func (s *session) playFile(ctx context.Context, filePath string) {
+    s.ui.ShowMessage(fmt.Sprintf("%sPlaying: %s%s", colorNotice, filepath.Base(filePath), colorReset))
+    s.ui.ShowMessage("")
+    s.ui.ShowMessage(barLine)
+    s.ui.ShowMessage("")
+    if p := s.activePlayer(); p != nil {
+        p.SetPaused(true)
+    }
+    command(ctx, s.config.Player.withDefaults().Path, append(s.config.Player.Args, filePath)...).Run()
+}
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"gophertube/internal/services"
+	"gophertube/internal/types"
+
+	"github.com/urfave/cli/v3"
+)
+
+var testVideos = []types.Video{
+	{Title: "Gopher Tutorial", Author: "Go Channel", ChannelID: "UCgo", URL: "https://www.youtube.com/watch?v=aaaaaaaaaaa", Duration: "12:05", Views: "1,234 views"},
+	{Title: "Cat Compilation", Author: "Cat Channel", ChannelID: "UCcat", URL: "https://www.youtube.com/watch?v=bbbbbbbbbbb", Duration: "3:07", Views: "56 views"},
+}
+
+// testSession returns a session on ui with the flags parsed from args and
+// the files of the app kept in a temporary home. Searches return
+// testVideos and are recorded in queries.
+func testSession(t *testing.T, ui UI, queries *[]string, args ...string) *session {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "config"))
+	t.Setenv("XDG_DATA_HOME", filepath.Join(home, "data"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(home, "cache"))
+
+	var s *session
+	cmd := &cli.Command{
+		Name:  "gophertube",
+		Flags: Flags(),
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			var err error
+			s, err = newSession(cmd, ui, fileConfig{})
+			return err
+		},
+	}
+	args = append([]string{"gophertube", "--config", filepath.Join(home, "gophertube.toml")}, args...)
+	if err := cmd.Run(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	s.search = func(ctx context.Context, query string, limit int, progress func(current, total int)) ([]types.Video, error) {
+		*queries = append(*queries, query)
+		return slices.Clone(testVideos), nil
+	}
+	return s
+}
+
+func TestSearchPickAction(t *testing.T) {
+	ui := newScriptedUI(
+		scriptStep{Match: menuSearchYouTube},
+		scriptStep{Text: "gophers"},
+		scriptStep{Match: "Cat Compilation"},
+		scriptStep{Match: "Block Channel"},
+		scriptStep{Cancel: true}, // back to the main menu
+	)
+	var queries []string
+	s := testSession(t, ui, &queries)
+	s.run(context.Background())
+
+	if !slices.Equal(queries, []string{"gophers"}) {
+		t.Errorf("searched for %q, want gophers", queries)
+	}
+	want := []string{"Select mode: ", "> ", "", "Action: ", "", "Select mode: "}
+	if !slices.Equal(ui.Prompts, want) {
+		t.Errorf("prompts %q, want %q", ui.Prompts, want)
+	}
+	results := ui.Lists[3]
+	if len(results) != 1 || !strings.Contains(results[0], "Gopher Tutorial") {
+		t.Errorf("results after blocking the channel: %q", results)
+	}
+	config, err := os.ReadFile(s.cmd.String(FlagConfig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "[blocklist]\nchannels = [\"UCcat\"]\n"; string(config) != want {
+		t.Errorf("config file %q, want %q", config, want)
+	}
+}
+
+func TestSearchKeyAction(t *testing.T) {
+	ui := newScriptedUI(
+		scriptStep{Match: menuSearchYouTube},
+		scriptStep{Text: "gophers"},
+		scriptStep{Match: "Gopher Tutorial", Choice: Choice{Key: defaultKeys.CopyURL}},
+		scriptStep{Cancel: true},
+	)
+	var queries []string
+	s := testSession(t, ui, &queries)
+	s.run(context.Background())
+
+	if ui.Clipboard != testVideos[0].URL {
+		t.Errorf("clipboard %q, want %q", ui.Clipboard, testVideos[0].URL)
+	}
+	// The key runs the action without the action menu
+	want := []string{"Select mode: ", "> ", "", "", "Select mode: "}
+	if !slices.Equal(ui.Prompts, want) {
+		t.Errorf("prompts %q, want %q", ui.Prompts, want)
+	}
+}
+
+func TestEscBackToResults(t *testing.T) {
+	ui := newScriptedUI(
+		scriptStep{Match: menuSearchYouTube},
+		scriptStep{Text: "gophers"},
+		scriptStep{Match: "Gopher Tutorial"},
+		scriptStep{Cancel: true}, // Esc in the action menu
+		scriptStep{Match: "Cat Compilation"},
+		scriptStep{Cancel: true}, // and again
+		scriptStep{Cancel: true}, // Esc in the results
+	)
+	var queries []string
+	s := testSession(t, ui, &queries)
+	s.run(context.Background())
+
+	want := []string{"Select mode: ", "> ", "", "Action: ", "", "Action: ", "", "Select mode: "}
+	if !slices.Equal(ui.Prompts, want) {
+		t.Errorf("prompts %q, want %q", ui.Prompts, want)
+	}
+	if len(queries) != 1 {
+		t.Errorf("searched %d times, want the results kept", len(queries))
+	}
+	for _, i := range []int{3, 5} {
+		if !slices.Equal(ui.Lists[i], ui.Lists[1]) {
+			t.Errorf("list %d is %q, want the results %q", i, ui.Lists[i], ui.Lists[1])
+		}
+	}
+}
+
+func TestOfflineMenu(t *testing.T) {
+	ui := newScriptedUI(
+		scriptStep{Match: menuYouTubeOffline},
+	)
+	var queries []string
+	s := testSession(t, ui, &queries, "--offline")
+	t.Cleanup(func() { services.SetOffline(false) })
+	s.checkOnline(context.Background())
+	s.run(context.Background())
+
+	want := []string{menuSearchDownloads, menuHistory, menuCachedSearches, menuYouTubeOffline}
+	if !slices.Equal(ui.Lists[0], want) {
+		t.Errorf("offline menu %q, want %q", ui.Lists[0], want)
+	}
+	if len(ui.Lists) != 2 || !slices.Equal(ui.Lists[1], want) {
+		t.Errorf("lists %q, want the offline menu twice", ui.Lists)
+	}
+	if ui.Pauses != 1 || !strings.Contains(strings.Join(ui.Messages, "\n"), "YouTube cannot be reached") {
+		t.Errorf("messages %q with %d pauses, want the offline notice", ui.Messages, ui.Pauses)
+	}
+	if len(queries) > 0 {
+		t.Errorf("searched for %q while offline", queries)
+	}
+}
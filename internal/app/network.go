@@ -0,0 +1,60 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gophertube/internal/services"
+)
+
+// networkConfig is the [network] table of the config file.
+type networkConfig struct {
+	Proxy            string `toml:"proxy"`                 // http://, https://, socks5:// or socks5h://
+	UserAgent        string `toml:"user_agent"`            // empty keeps the built-in one
+	Timeout          string `toml:"timeout"`               // per search request, e.g. "10s"
+	ThumbnailTimeout string `toml:"thumbnail_timeout"`     // per thumbnail request
+	Retries          *int   `toml:"retries"`               // extra attempts after a failure
+	RetryDelay       string `toml:"retry_delay"`           // first backoff, doubled per retry
+	MaxRetryDelay    string `toml:"max_retry_delay"`       // backoff limit
+	ThumbConcurrency int    `toml:"thumbnail_concurrency"` // 0 keeps the default
+	CABundle         string `toml:"ca_bundle"`             // PEM file with additional CAs
+	IPVersion        int    `toml:"ip_version"`            // 4 or 6, 0 for either
+}
+
+// resolve fills in the defaults for unset keys.
+func (c networkConfig) resolve() (services.NetworkConfig, error) {
+	nc := services.DefaultNetworkConfig
+	nc.Proxy = c.Proxy
+	nc.CABundle = os.ExpandEnv(c.CABundle)
+	nc.IPVersion = c.IPVersion
+	if c.UserAgent != "" {
+		nc.UserAgent = c.UserAgent
+	}
+	if c.Retries != nil {
+		nc.Retries = *c.Retries
+	}
+	if c.ThumbConcurrency != 0 {
+		nc.ThumbnailConcurrency = c.ThumbConcurrency
+	}
+	for _, d := range []struct {
+		key   string
+		value string
+		dst   *time.Duration
+	}{
+		{"timeout", c.Timeout, &nc.Timeout},
+		{"thumbnail_timeout", c.ThumbnailTimeout, &nc.ThumbnailTimeout},
+		{"retry_delay", c.RetryDelay, &nc.RetryDelay},
+		{"max_retry_delay", c.MaxRetryDelay, &nc.MaxRetryDelay},
+	} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil || v <= 0 {
+			return nc, fmt.Errorf("network: invalid %s %q", d.key, d.value)
+		}
+		*d.dst = v
+	}
+	return nc, nil
+}
@@ -0,0 +1,262 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"gophertube/internal/player"
+	"gophertube/internal/services"
+	"gophertube/internal/types"
+)
+
+// Steps of the now-playing controls.
+const (
+	seekShort   = 5 * time.Second
+	seekLong    = time.Minute
+	volumeStep  = 5
+	speedFactor = 1.1
+)
+
+// nowPlayingHelp lists the keys of the now-playing screen.
+var nowPlayingHelp = []string{
+	"Space pause • ←/→ seek 5s • ↑/↓ seek 1m • n/p next/previous",
+	"+/- volume • [/] speed • Backspace normal speed",
+	"q/Esc back, keep playing • s stop",
+}
+
+// startAudio runs mpv headless on the audio of videos, as a playlist, and
+// records them in the history.
+func (s *session) startAudio(ctx context.Context, videos []types.Video) (*player.Player, error) {
+	cfg := s.config.Player.withDefaults()
+	path, err := exec.LookPath(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	args := append(s.mpvArgs(), cfg.AudioArgs...)
+	args = append(args, "--no-video", "--ytdl-format="+cfg.AudioFormat)
+	for _, v := range videos {
+		args = append(args, v.URL)
+	}
+	p, err := player.Start(command(ctx, path, args...))
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range videos {
+		services.AppendHistory(v)
+	}
+	return p, nil
+}
+
+// activePlayer returns the background player, or nil once it stopped.
+func (s *session) activePlayer() *player.Player {
+	if s.player != nil && !s.player.Running() {
+		s.player, s.playing = nil, nil
+	}
+	return s.player
+}
+
+// stopPlayer ends background playback.
+func (s *session) stopPlayer() {
+	if p := s.activePlayer(); p != nil {
+		p.Quit()
+	}
+	s.player, s.playing = nil, nil
+}
+
+// playerStatus is the one-line summary of background playback shown in
+// list headers, empty when nothing plays.
+func (s *session) playerStatus() string {
+	p := s.activePlayer()
+	if p == nil {
+		return ""
+	}
+	st, err := p.Status()
+	if err != nil {
+		return ""
+	}
+	state := "▶"
+	if st.Paused {
+		state = "⏸"
+	}
+	title := st.Title
+	if st.Index >= 0 && st.Index < len(s.playing) {
+		title = s.playing[st.Index].Title
+	}
+	line := fmt.Sprintf("%s♪ %s %s%s • %s", colorSuccess, state, firstNonEmpty(title, "Loading..."), colorReset, formatDuration(st.Position))
+	if st.Duration > 0 {
+		line += " / " + formatDuration(st.Duration)
+	}
+	if st.Count > 1 && st.Index >= 0 {
+		line += fmt.Sprintf(" • %d of %d", st.Index+1, st.Count)
+	}
+	return line
+}
+
+// whilePlaying returns header for PickOptions.LiveHeader while the
+// background player runs, so its status line keeps up with playback. Nothing
+// changes on its own otherwise and the header is left alone.
+func (s *session) whilePlaying(header func() string) func() string {
+	if s.activePlayer() == nil {
+		return nil
+	}
+	return header
+}
+
+// nowPlaying shows what the background player is playing and passes the
+// keys on to it until playback ends, is stopped, or the user goes back to
+// browsing while it keeps playing.
+func (s *session) nowPlaying(ctx context.Context) {
+	p := s.activePlayer()
+	if p == nil {
+		return
+	}
+	keys := make(chan string, 8)
+	stopKeys := s.ui.WatchKeys(func(key string) bool {
+		select {
+		case keys <- key:
+		default:
+		}
+		return false
+	})
+
+	tick := time.NewTicker(500 * time.Millisecond)
+	defer tick.Stop()
+	leave := false
+loop:
+	for {
+		st, err := p.Status()
+		if err != nil {
+			break
+		}
+		s.ui.DrawScreen(nowPlayingScreen(st, s.playing, s.ui.ScreenWidth()))
+
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-p.Done():
+			break loop
+		case <-tick.C:
+		case key := <-keys:
+			switch controlPlayer(p, key) {
+			case playerBack:
+				leave = true
+				break loop
+			case playerStop:
+				p.Quit()
+				break loop
+			}
+		}
+	}
+	stopKeys()
+	if leave || ctx.Err() != nil {
+		return
+	}
+
+	err := s.player.Err()
+	s.player, s.playing = nil, nil
+	if err != nil {
+		s.ui.ShowMessage("")
+		s.ui.ShowMessage(colorError + "Playback failed: " + err.Error() + colorReset)
+		s.ui.ShowMessage(colorText + "Make sure yt-dlp is installed." + colorReset)
+		s.ui.Pause("Press any key to return...")
+	}
+}
+
+// What the now-playing screen does after a key.
+const (
+	playerStay = iota
+	playerBack
+	playerStop
+)
+
+// controlPlayer runs the command bound to key and says whether to stay on
+// the now-playing screen.
+func controlPlayer(p *player.Player, key string) int {
+	switch key {
+	case "q", "Q", "\x1b", "\x03":
+		return playerBack
+	case "s", "S":
+		return playerStop
+	case " ":
+		p.TogglePause()
+	case "\x1b[D", "\x1bOD":
+		p.Seek(-seekShort)
+	case "\x1b[C", "\x1bOC":
+		p.Seek(seekShort)
+	case "\x1b[B", "\x1bOB":
+		p.Seek(-seekLong)
+	case "\x1b[A", "\x1bOA":
+		p.Seek(seekLong)
+	case "n", ">":
+		p.Next()
+	case "p", "<":
+		p.Prev()
+	case "+", "=", "0":
+		p.AddVolume(volumeStep)
+	case "-", "9":
+		p.AddVolume(-volumeStep)
+	case "]":
+		p.MultiplySpeed(speedFactor)
+	case "[":
+		p.MultiplySpeed(1 / speedFactor)
+	case "\x7f", "\b":
+		p.MultiplySpeed(0)
+	}
+	return playerStay
+}
+
+// nowPlayingScreen is the now-playing screen for st, width columns wide.
+func nowPlayingScreen(st player.Status, videos []types.Video, width int) []string {
+	var v types.Video
+	if st.Index >= 0 && st.Index < len(videos) {
+		v = videos[st.Index]
+	}
+	title := firstNonEmpty(v.Title, st.Title, "Loading...")
+	state := colorSuccess + "▶" + colorReset
+	if st.Paused {
+		state = colorNotice + "⏸" + colorReset
+	}
+
+	var lines []string
+	line := func(format string, args ...any) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+	line("%s %s%s%s", state, colorInfo, title, colorReset)
+	if v.Author != "" {
+		line("%s%s%s", colorText, v.Author, colorReset)
+	}
+	line("")
+	line("%s", progressLine(st.Position, st.Duration, width))
+	line("")
+	status := fmt.Sprintf("Volume %.0f%% • Speed %.2fx", st.Volume, st.Speed)
+	if st.Count > 1 && st.Index >= 0 {
+		status += fmt.Sprintf(" • %d of %d in queue", st.Index+1, st.Count)
+	}
+	line("%s%s%s", colorText, status, colorReset)
+	line("")
+	line("%s", barLine)
+	for _, h := range nowPlayingHelp {
+		line("%s%s%s", colorNotice, h, colorReset)
+	}
+	return lines
+}
+
+// progressLine renders elapsed and total time around a bar filling width
+// columns in all.
+func progressLine(pos, total time.Duration, width int) string {
+	elapsed, length := formatDuration(pos), "--:--"
+	if total > 0 {
+		length = formatDuration(total)
+	}
+	barWidth := min(max(width-len(elapsed)-len(length)-2, 10), 60)
+	filled := 0
+	if total > 0 {
+		filled = min(int(float64(barWidth)*pos.Seconds()/total.Seconds()), barWidth)
+	}
+	return fmt.Sprintf("%s %s%s%s%s %s", elapsed,
+		colorAccent, strings.Repeat("█", filled), colorReset, strings.Repeat("░", barWidth-filled),
+		length)
+}
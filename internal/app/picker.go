@@ -0,0 +1,78 @@
+package app
+
+import "errors"
+
+// ErrCancelled is returned by a Picker or Prompter when the user backs out
+// (Esc, Ctrl+C or an empty selection).
+var ErrCancelled = errors.New("cancelled by user")
+
+// PickOptions describes how a list should be presented. Implementations are
+// free to ignore the purely cosmetic fields.
+type PickOptions struct {
+	Prompt    string
+	Header    string
+	Preview   string   // shell command run for the highlighted line
+	Query     string   // initial filter text
+	Delimiter string   // literal field delimiter used by WithNth and Preview, tab by default
+	WithNth   string   // fields shown to the user, fzf syntax, all by default
+	Expect    []string // extra keys that accept the current line
+	Ansi      bool
+	// LiveHeader, when set, is asked for the header again every second
+	// while the list is open, for status that changes on its own.
+	LiveHeader func() string
+}
+
+// Choice is the outcome of Picker.ChooseOne.
+type Choice struct {
+	Index int    // index into the items, -1 when only a key was pressed
+	Key   string // key from PickOptions.Expect, empty for Enter
+}
+
+// Picker selects entries from a list.
+type Picker interface {
+	ChooseOne(opts PickOptions, items []string) (Choice, error)
+	ChooseMany(opts PickOptions, items []string) ([]int, error)
+}
+
+// Prompter reads free text and reports progress and messages to the user.
+type Prompter interface {
+	PromptText(prompt string) (string, error)
+	ShowMessage(msg string)
+	ShowProgress(current, total int)
+	// Pause shows msg and blocks until the user acknowledges it.
+	Pause(msg string)
+}
+
+// Screen is what the interactive modes ask of the terminal besides lists
+// and prompts.
+type Screen interface {
+	// WatchKeys passes every key typed to onKey, escape sequences in one
+	// piece, until onKey returns true or stop is called.
+	WatchKeys(onKey func(key string) bool) (stop func())
+	// DrawScreen replaces what is shown with lines.
+	DrawScreen(lines []string)
+	// ScreenWidth is the number of columns lines can fill.
+	ScreenWidth() int
+	// CopyToClipboard puts text on the clipboard.
+	CopyToClipboard(text string)
+}
+
+// UI is everything the interactive modes need from the terminal.
+type UI interface {
+	Picker
+	Prompter
+	Screen
+}
+
+// chooseString is a convenience for plain menus where the selected item
+// itself is the interesting value.
+func chooseString(p Picker, prompt string, items []string) (string, error) {
+	c, err := p.ChooseOne(PickOptions{Prompt: prompt}, items)
+	if err != nil {
+		return "", err
+	}
+	if c.Index < 0 || c.Index >= len(items) {
+		return "", ErrCancelled
+	}
+	return items[c.Index], nil
+}
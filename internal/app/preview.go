@@ -0,0 +1,91 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"gophertube/internal/preview"
+	"gophertube/internal/services"
+
+	"github.com/urfave/cli/v3"
+)
+
+// previewThumbCommand is the helper fzf runs to draw a thumbnail in the
+// preview window. It is hidden since it is of no use on its own.
+func previewThumbCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "preview-thumb",
+		Usage:     "Render an image in the terminal (used by the fzf preview)",
+		ArgsUsage: "<path>",
+		Hidden:    true,
+		Flags: []cli.Flag{
+			&cli.IntFlag{
+				Name:    "width",
+				Usage:   "width of the preview area in cells",
+				Sources: cli.EnvVars("FZF_PREVIEW_COLUMNS"),
+				Value:   40,
+			},
+			&cli.IntFlag{
+				Name:    "height",
+				Usage:   "height of the preview area in cells",
+				Sources: cli.EnvVars("FZF_PREVIEW_LINES"),
+				Value:   20,
+			},
+			&cli.StringFlag{
+				Name:  "url",
+				Usage: "download the thumbnail from here when <path> does not exist yet",
+			},
+			&cli.StringFlag{
+				Name:    "protocol",
+				Usage:   "kitty, sixel, iterm2 or blocks (detected when empty)",
+				Sources: cli.EnvVars(preview.ProtocolEnv),
+			},
+		},
+		Action: previewThumbAction,
+	}
+}
+
+func previewThumbAction(ctx context.Context, cmd *cli.Command) error {
+	path := cmd.Args().First()
+	proto, ok := preview.ParseProtocol(cmd.String("protocol"))
+	if !ok {
+		proto = preview.Detect()
+	}
+	if url := cmd.String("url"); url != "" && !fileExists(path) {
+		// The highlighted item jumps the queue of background downloads,
+		// fzf kills this process when the selection moves on.
+		path = services.FetchThumbnail(ctx, url)
+	}
+	if path == "" {
+		fmt.Println("No image preview available")
+		return nil
+	}
+	if err := preview.Render(os.Stdout, path, int(cmd.Int("width")), int(cmd.Int("height")), proto); err != nil {
+		// The preview pane is the only place this would show up, a short
+		// notice reads better there than an error.
+		fmt.Println("No image preview available")
+	}
+	return nil
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Size() > 0
+}
+
+// selfCommand returns the shell-quoted path of the running binary, used to
+// call back into gophertube from fzf preview commands.
+func selfCommand() string {
+	exe, err := os.Executable()
+	if err != nil {
+		exe = os.Args[0]
+	}
+	return shellQuote(exe)
+}
+
+// shellQuote wraps s in single quotes for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
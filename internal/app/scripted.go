@@ -0,0 +1,119 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+)
+
+// scriptStep is one canned answer for scriptedUI.
+type scriptStep struct {
+	Text   string // answer to PromptText
+	Match  string // choose the first item containing Match
+	Choice Choice // used as-is when Match is empty
+	Many   []int  // answer to ChooseMany
+	Cancel bool   // behave as if Esc was pressed
+}
+
+// scriptedUI replays a fixed list of answers instead of asking the user so
+// that mode flows can be driven without a terminal. Running out of steps
+// behaves like pressing Esc, which makes every flow terminate.
+type scriptedUI struct {
+	steps []scriptStep
+
+	// Recorded interaction, in order.
+	Prompts   []string
+	Lists     [][]string // items of every list shown
+	Messages  []string
+	Pauses    int
+	Clipboard string
+	Screens   [][]string
+}
+
+func newScriptedUI(steps ...scriptStep) *scriptedUI {
+	return &scriptedUI{steps: steps}
+}
+
+func (s *scriptedUI) next(prompt string) (scriptStep, bool) {
+	s.Prompts = append(s.Prompts, prompt)
+	if len(s.steps) == 0 {
+		return scriptStep{}, false
+	}
+	st := s.steps[0]
+	s.steps = s.steps[1:]
+	return st, !st.Cancel
+}
+
+// list records the items of a list without their colors.
+func (s *scriptedUI) list(items []string) {
+	list := make([]string, len(items))
+	for i, it := range items {
+		list[i] = ansiRegex.ReplaceAllString(it, "")
+	}
+	s.Lists = append(s.Lists, list)
+}
+
+func (s *scriptedUI) ChooseOne(opts PickOptions, items []string) (Choice, error) {
+	s.list(items)
+	st, ok := s.next(opts.Prompt)
+	if !ok {
+		return Choice{Index: -1}, ErrCancelled
+	}
+	if st.Match == "" {
+		return st.Choice, nil
+	}
+	for i, it := range s.Lists[len(s.Lists)-1] {
+		if strings.Contains(it, st.Match) {
+			return Choice{Index: i, Key: st.Choice.Key}, nil
+		}
+	}
+	return Choice{Index: -1}, fmt.Errorf("scripted UI: no item matches %q", st.Match)
+}
+
+func (s *scriptedUI) ChooseMany(opts PickOptions, items []string) ([]int, error) {
+	s.list(items)
+	st, ok := s.next(opts.Prompt)
+	if !ok || len(st.Many) == 0 {
+		return nil, ErrCancelled
+	}
+	return st.Many, nil
+}
+
+func (s *scriptedUI) PromptText(prompt string) (string, error) {
+	st, ok := s.next(prompt)
+	if !ok || st.Text == "" {
+		return "", ErrCancelled
+	}
+	return st.Text, nil
+}
+
+func (s *scriptedUI) ShowMessage(msg string) {
+	s.Messages = append(s.Messages, ansiRegex.ReplaceAllString(msg, ""))
+}
+
+func (s *scriptedUI) ShowProgress(current, total int) {}
+
+func (s *scriptedUI) Pause(msg string) {
+	s.Messages = append(s.Messages, ansiRegex.ReplaceAllString(msg, ""))
+	s.Pauses++
+}
+
+// WatchKeys never sees a key, there is no keyboard.
+func (s *scriptedUI) WatchKeys(onKey func(key string) bool) (stop func()) {
+	return func() {}
+}
+
+func (s *scriptedUI) CopyToClipboard(text string) {
+	s.Clipboard = text
+}
+
+func (s *scriptedUI) DrawScreen(lines []string) {
+	screen := make([]string, len(lines))
+	for i, l := range lines {
+		screen[i] = ansiRegex.ReplaceAllString(l, "")
+	}
+	s.Screens = append(s.Screens, screen)
+}
+
+func (s *scriptedUI) ScreenWidth() int {
+	return 80
+}
@@ -0,0 +1,219 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gophertube/internal/types"
+)
+
+// shortsMaxLength is the longest a video can be to count as a short.
+const shortsMaxLength = time.Minute
+
+// sortKey is an ordering of the result list. The zero value keeps
+// YouTube's relevance order.
+type sortKey int
+
+const (
+	sortRelevance sortKey = iota
+	sortViews
+	sortNewest
+	sortLongest
+	sortShortest
+	sortChannel
+	sortKeyCount
+)
+
+func (k sortKey) String() string {
+	switch k {
+	case sortViews:
+		return "most viewed"
+	case sortNewest:
+		return "newest"
+	case sortLongest:
+		return "longest"
+	case sortShortest:
+		return "shortest"
+	case sortChannel:
+		return "channel"
+	}
+	return "relevance"
+}
+
+// next cycles through the orderings.
+func (k sortKey) next() sortKey {
+	return (k + 1) % sortKeyCount
+}
+
+// listFilter hides results that do not match. The zero value shows all.
+type listFilter struct {
+	MinLength  time.Duration
+	MaxLength  time.Duration // 0 means no upper bound
+	MinViews   int64
+	Channel    string
+	HideShorts bool
+	HideLive   bool
+}
+
+func (f listFilter) keep(v types.Video) bool {
+	if f.HideLive && v.Live {
+		return false
+	}
+	if f.HideShorts && !v.Live && v.Length > 0 && v.Length <= shortsMaxLength {
+		return false
+	}
+	if (f.MinLength > 0 || f.MaxLength > 0) && v.Live {
+		return false
+	}
+	if f.MinLength > 0 && v.Length < f.MinLength {
+		return false
+	}
+	if f.MaxLength > 0 && v.Length > f.MaxLength {
+		return false
+	}
+	if f.MinViews > 0 && v.ViewCount < f.MinViews {
+		return false
+	}
+	if f.Channel != "" && v.Author != f.Channel {
+		return false
+	}
+	return true
+}
+
+// String describes the active filters, empty when there are none.
+func (f listFilter) String() string {
+	var parts []string
+	switch {
+	case f.MinLength > 0 && f.MaxLength > 0:
+		parts = append(parts, fmt.Sprintf("%s–%s", formatDuration(f.MinLength), formatDuration(f.MaxLength)))
+	case f.MinLength > 0:
+		parts = append(parts, "over "+formatDuration(f.MinLength))
+	case f.MaxLength > 0:
+		parts = append(parts, "under "+formatDuration(f.MaxLength))
+	}
+	if f.MinViews > 0 {
+		parts = append(parts, formatCount(f.MinViews)+"+ views")
+	}
+	if f.Channel != "" {
+		parts = append(parts, f.Channel)
+	}
+	if f.HideShorts {
+		parts = append(parts, "no shorts")
+	}
+	if f.HideLive {
+		parts = append(parts, "no live")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// listView is the sort order and filter applied to a result list.
+type listView struct {
+	Sort   sortKey
+	Filter listFilter
+}
+
+// apply returns the indexes of the videos to show, in display order.
+func (lv listView) apply(videos []types.Video) []int {
+	idx := make([]int, 0, len(videos))
+	for i, v := range videos {
+		if lv.Filter.keep(v) {
+			idx = append(idx, i)
+		}
+	}
+	var less func(a, b types.Video) bool
+	switch lv.Sort {
+	case sortViews:
+		less = func(a, b types.Video) bool { return a.ViewCount > b.ViewCount }
+	case sortNewest:
+		less = func(a, b types.Video) bool { return a.PublishedAt.After(b.PublishedAt) }
+	case sortLongest:
+		less = func(a, b types.Video) bool { return a.Length > b.Length }
+	case sortShortest:
+		// Unknown lengths (live streams) go last
+		less = func(a, b types.Video) bool {
+			if a.Length == 0 || b.Length == 0 {
+				return a.Length != 0
+			}
+			return a.Length < b.Length
+		}
+	case sortChannel:
+		less = func(a, b types.Video) bool { return strings.ToLower(a.Author) < strings.ToLower(b.Author) }
+	default:
+		return idx
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return less(videos[idx[i]], videos[idx[j]]) })
+	return idx
+}
+
+// filterMenu lets the user change one aspect of f and returns the result.
+func (s *session) filterMenu(f listFilter, videos []types.Video) listFilter {
+	onOff := func(b bool) string {
+		if b {
+			return "on"
+		}
+		return "off"
+	}
+	type option struct {
+		label string
+		apply func(*listFilter) bool
+	}
+	duration := func(min, max time.Duration) func(*listFilter) bool {
+		return func(f *listFilter) bool { f.MinLength, f.MaxLength = min, max; return true }
+	}
+	views := func(n int64) func(*listFilter) bool {
+		return func(f *listFilter) bool { f.MinViews = n; return true }
+	}
+	options := []option{
+		{"Duration: any", duration(0, 0)},
+		{"Duration: under 4 minutes", duration(0, 4*time.Minute)},
+		{"Duration: 4 to 20 minutes", duration(4*time.Minute, 20*time.Minute)},
+		{"Duration: over 20 minutes", duration(20*time.Minute, 0)},
+		{"Minimum views: any", views(0)},
+		{"Minimum views: 1K", views(1e3)},
+		{"Minimum views: 10K", views(1e4)},
+		{"Minimum views: 100K", views(1e5)},
+		{"Minimum views: 1M", views(1e6)},
+		{"Channel: any", func(f *listFilter) bool { f.Channel = ""; return true }},
+		{"Channel: choose...", func(f *listFilter) bool {
+			ch, err := s.chooseChannel(videos)
+			f.Channel = ch
+			return err == nil
+		}},
+		{"Hide shorts: " + onOff(f.HideShorts), func(f *listFilter) bool { f.HideShorts = !f.HideShorts; return true }},
+		{"Hide live streams: " + onOff(f.HideLive), func(f *listFilter) bool { f.HideLive = !f.HideLive; return true }},
+		{"Clear all filters", func(f *listFilter) bool { *f = listFilter{}; return true }},
+	}
+	labels := make([]string, len(options))
+	for i, o := range options {
+		labels[i] = o.label
+	}
+	header := "Active: " + f.String()
+	if f.String() == "" {
+		header = "No filters active"
+	}
+	c, err := s.ui.ChooseOne(PickOptions{Prompt: "Filter: ", Header: header}, labels)
+	if err != nil || c.Index < 0 {
+		return f
+	}
+	updated := f
+	if !options[c.Index].apply(&updated) {
+		return f
+	}
+	return updated
+}
+
+// chooseChannel asks for one of the channels present in videos.
+func (s *session) chooseChannel(videos []types.Video) (string, error) {
+	seen := make(map[string]bool)
+	var channels []string
+	for _, v := range videos {
+		if v.Author != "" && !seen[v.Author] {
+			seen[v.Author] = true
+			channels = append(channels, v.Author)
+		}
+	}
+	sort.Strings(channels)
+	return chooseString(s.ui, "Channel: ", channels)
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"gophertube/internal/services"
+	"gophertube/internal/types"
+)
+
+// menuChooseStream lists the streams of a video in the action and quality
+// menus.
+const menuChooseStream = "Choose Stream..."
+
+// streamHeader names the columns of streamLine after the format ID.
+const streamHeader = "Quality    Codec  Ext   Bitrate     Size         Notes"
+
+// chooseStream lets the user pick a stream of video and watch or download
+// it.
+func (s *session) chooseStream(ctx context.Context, video types.Video) {
+	f, ok := s.pickStream(ctx, video)
+	if !ok {
+		return
+	}
+	choice, err := chooseString(s.ui, streamLabel(f)+": ", []string{"Watch", "Download"})
+	if err != nil {
+		return
+	}
+	if choice == "Download" {
+		s.downloadFormat(ctx, video, streamLabel(f), streamFormat(f), !f.HasVideo())
+		return
+	}
+	s.watchFormat(ctx, video, streamFormat(f), !f.HasVideo())
+}
+
+// pickStream asks yt-dlp for the streams video really has and lets the
+// user choose one.
+func (s *session) pickStream(ctx context.Context, video types.Video) (services.Format, bool) {
+	s.ui.ShowMessage(colorInfo + "Fetching the available streams..." + colorReset)
+	formats, err := services.VideoFormats(ctx, video.URL)
+	if err != nil {
+		if ctx.Err() == nil {
+			s.ui.ShowMessage(colorError + "Could not list the streams: " + err.Error() + colorReset)
+			s.ui.Pause("Press any key to return...")
+		}
+		return services.Format{}, false
+	}
+	// Streams can only differ in their ID, e.g. DASH and HLS variants
+	idWidth := len("ID")
+	for _, f := range formats {
+		idWidth = max(idWidth, len(f.ID))
+	}
+	lines := make([]string, len(formats))
+	for i, f := range formats {
+		lines[i] = streamLine(f, idWidth)
+	}
+	header := fmt.Sprintf("%-*s %s", idWidth, "ID", streamHeader)
+	c, err := s.ui.ChooseOne(PickOptions{Prompt: "Stream: ", Header: header}, lines)
+	if err != nil || c.Index < 0 || c.Index >= len(formats) {
+		return services.Format{}, false
+	}
+	return formats[c.Index], true
+}
+
+// streamFormat is the yt-dlp format fetching f. Streams without sound get
+// the best audio added.
+func streamFormat(f services.Format) string {
+	if f.HasVideo() && !f.HasAudio() {
+		return f.ID + "+bestaudio"
+	}
+	return f.ID
+}
+
+// streamLabel names f in messages, e.g. "1080p60 vp9".
+func streamLabel(f services.Format) string {
+	return streamQuality(f) + " " + shortCodec(f)
+}
+
+// streamLine describes f in a row of the stream picker, starting with its
+// format ID padded to idWidth.
+func streamLine(f services.Format, idWidth int) string {
+	bitrate, size := "", ""
+	if f.Bitrate > 0 {
+		bitrate = fmt.Sprintf("%.0f kbps", f.Bitrate)
+		if f.Bitrate >= 1000 {
+			bitrate = fmt.Sprintf("%.1f Mbps", f.Bitrate/1000)
+		}
+	}
+	if f.Size > 0 {
+		size = "~" + formatBytes(f.Size)
+	}
+	var notes []string
+	if f.DynamicRange != "" && f.DynamicRange != "SDR" {
+		notes = append(notes, f.DynamicRange)
+	}
+	switch {
+	case f.HasVideo() && !f.HasAudio():
+		notes = append(notes, "+ best audio")
+	case f.HasVideo():
+		notes = append(notes, "with audio")
+	}
+	if f.Note != "" && !strings.HasPrefix(f.Note, streamQuality(f)) {
+		notes = append(notes, f.Note)
+	}
+	return fmt.Sprintf("%-*s %-10s %-6s %-5s %-11s %-12s %s", idWidth, f.ID,
+		streamQuality(f), shortCodec(f), f.Ext, bitrate, size, strings.Join(notes, ", "))
+}
+
+// streamQuality is the height and framerate of f, or "audio".
+func streamQuality(f services.Format) string {
+	if !f.HasVideo() {
+		return "audio"
+	}
+	if f.Height == 0 {
+		return "video"
+	}
+	q := fmt.Sprintf("%dp", f.Height)
+	if f.Fps > 30 {
+		q += fmt.Sprintf("%.0f", f.Fps)
+	}
+	return q
+}
+
+// shortCodec drops the profile from the codec of f, e.g. "avc1.64001F".
+func shortCodec(f services.Format) string {
+	codec := f.VideoCodec
+	if codec == "" {
+		codec = f.AudioCodec
+	}
+	codec, _, _ = strings.Cut(codec, ".")
+	return codec
+}
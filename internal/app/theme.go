@@ -0,0 +1,232 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// theme is how the UI looks: the palette as SGR parameters (e.g. "1;36"),
+// the fzf --color spec and the layout of the preview.
+type theme struct {
+	Accent, Info, Success, Notice, Error, Text, Muted string
+
+	FzfColors     string
+	Border        string
+	Margin        string
+	PreviewWindow string
+	PreviewWidth  int // thumbnail width in percent of the preview
+	PreviewHeight int // thumbnail height in percent of the preview
+}
+
+// themes are the built-in themes, "dark" is the default.
+var themes = map[string]theme{
+	"dark": {
+		// fzf keeps its own colors, as it did before themes
+		Accent: "1;35", Info: "1;36", Success: "1;32", Notice: "1;33", Error: "1;31", Text: "1;37", Muted: "0;37",
+		Border: "rounded", Margin: "1,1", PreviewWindow: "wrap",
+		PreviewWidth: 90, PreviewHeight: 60,
+	},
+	"light": {
+		Accent: "38;5;90", Info: "38;5;24", Success: "38;5;28", Notice: "38;5;130", Error: "38;5;160", Text: "1;38;5;235", Muted: "38;5;242",
+		FzfColors: "light",
+		Border:    "rounded", Margin: "1,1", PreviewWindow: "wrap",
+		PreviewWidth: 90, PreviewHeight: 60,
+	},
+	"high-contrast": {
+		Accent: "1;95", Info: "1;96", Success: "1;92", Notice: "1;93", Error: "1;91", Text: "1;97", Muted: "97",
+		FzfColors: "16,fg:15,bg:0,hl:11,fg+:0,bg+:11,hl+:0,info:14,prompt:10,pointer:11,marker:10,header:15,border:15",
+		Border:    "double", Margin: "1,1", PreviewWindow: "wrap",
+		PreviewWidth: 90, PreviewHeight: 60,
+	},
+}
+
+// themeConfig is the [theme] table of the config file: a built-in theme to
+// start from and the values to change in it.
+type themeConfig struct {
+	Name string `toml:"name"`
+
+	Accent  string `toml:"accent"`
+	Info    string `toml:"info"`
+	Success string `toml:"success"`
+	Notice  string `toml:"notice"`
+	Error   string `toml:"error"`
+	Text    string `toml:"text"`
+	Muted   string `toml:"muted"`
+
+	FzfColors     string `toml:"fzf_colors"`
+	Border        string `toml:"border"`
+	Margin        string `toml:"margin"`
+	PreviewWindow string `toml:"preview_window"`
+	PreviewWidth  int    `toml:"preview_width"`
+	PreviewHeight int    `toml:"preview_height"`
+}
+
+// resolve builds the theme described by c. With noColor, as asked for by
+// NO_COLOR, the palette is dropped and fzf is told to use no colors.
+func (c themeConfig) resolve(noColor bool) (theme, error) {
+	name := strings.ToLower(strings.TrimSpace(c.Name))
+	if name == "" {
+		name = "dark"
+	}
+	t, ok := themes[name]
+	if !ok {
+		return theme{}, fmt.Errorf("theme: unknown theme %q (built in: %s)", c.Name, strings.Join(themeNames(), ", "))
+	}
+
+	for _, o := range []struct {
+		key   string
+		value string
+		dst   *string
+	}{
+		{"accent", c.Accent, &t.Accent},
+		{"info", c.Info, &t.Info},
+		{"success", c.Success, &t.Success},
+		{"notice", c.Notice, &t.Notice},
+		{"error", c.Error, &t.Error},
+		{"text", c.Text, &t.Text},
+		{"muted", c.Muted, &t.Muted},
+	} {
+		if o.value == "" {
+			continue
+		}
+		sgr, err := parseColor(o.value)
+		if err != nil {
+			return theme{}, fmt.Errorf("theme: %s: %w", o.key, err)
+		}
+		*o.dst = sgr
+	}
+	if c.FzfColors != "" {
+		// Later fzf --color settings override earlier ones
+		t.FzfColors = strings.TrimPrefix(t.FzfColors+","+c.FzfColors, ",")
+	}
+	t.Border = firstNonEmpty(c.Border, t.Border)
+	t.Margin = firstNonEmpty(c.Margin, t.Margin)
+	t.PreviewWindow = firstNonEmpty(c.PreviewWindow, t.PreviewWindow)
+	for _, r := range []struct {
+		key   string
+		value int
+		dst   *int
+	}{
+		{"preview_width", c.PreviewWidth, &t.PreviewWidth},
+		{"preview_height", c.PreviewHeight, &t.PreviewHeight},
+	} {
+		if r.value == 0 {
+			continue
+		}
+		if r.value < 10 || r.value > 100 {
+			return theme{}, fmt.Errorf("theme: %s must be between 10 and 100, got %d", r.key, r.value)
+		}
+		*r.dst = r.value
+	}
+
+	if noColor {
+		t.Accent, t.Info, t.Success, t.Notice, t.Error, t.Text, t.Muted = "", "", "", "", "", "", ""
+		t.FzfColors = "bw"
+	}
+	return t, nil
+}
+
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyTheme makes t the look of everything drawn from now on.
+func applyTheme(t theme) {
+	sgr := func(p string) string {
+		if p == "" {
+			return ""
+		}
+		return "\033[" + p + "m"
+	}
+	colorAccent = sgr(t.Accent)
+	colorInfo = sgr(t.Info)
+	colorSuccess = sgr(t.Success)
+	colorNotice = sgr(t.Notice)
+	colorError = sgr(t.Error)
+	colorText = sgr(t.Text)
+	colorMuted = sgr(t.Muted)
+	colorReset = "\033[0m"
+	if colorAccent+colorInfo+colorSuccess+colorNotice+colorError+colorText+colorMuted == "" {
+		colorReset = ""
+	}
+	barLine = colorAccent + "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━" + colorReset
+
+	fzfColors = t.FzfColors
+	fzfBorder = t.Border
+	fzfMargin = t.Margin
+	fzfPreviewWrap = t.PreviewWindow
+	previewWidth = t.PreviewWidth
+	previewHeight = t.PreviewHeight
+}
+
+// ansiColors are the names of the 16 terminal colors, by SGR offset.
+var ansiColors = map[string]int{
+	"black": 0, "red": 1, "green": 2, "yellow": 3, "blue": 4, "magenta": 5, "cyan": 6, "white": 7,
+}
+
+// ansiStyles are the text attributes a color may start with.
+var ansiStyles = map[string]string{
+	"bold": "1", "dim": "2", "italic": "3", "underline": "4",
+}
+
+// parseColor turns a color like "cyan", "bold bright-red", "208" (from the
+// 256 color palette) or "#ff8800" into SGR parameters. Raw parameters such
+// as "1;36" are passed through.
+func parseColor(s string) (string, error) {
+	var params []string
+	for _, word := range strings.Fields(strings.ToLower(s)) {
+		if p, ok := ansiStyles[word]; ok {
+			params = append(params, p)
+			continue
+		}
+		if name, ok := strings.CutPrefix(word, "bright-"); ok {
+			if n, ok := ansiColors[name]; ok {
+				params = append(params, strconv.Itoa(90+n))
+				continue
+			}
+		}
+		if n, ok := ansiColors[word]; ok {
+			params = append(params, strconv.Itoa(30+n))
+			continue
+		}
+		if hex, ok := strings.CutPrefix(word, "#"); ok && len(hex) == 6 {
+			if rgb, err := strconv.ParseUint(hex, 16, 32); err == nil {
+				params = append(params, fmt.Sprintf("38;2;%d;%d;%d", rgb>>16, rgb>>8&0xff, rgb&0xff))
+				continue
+			}
+		}
+		if n, err := strconv.Atoi(word); err == nil && n >= 0 && n <= 255 {
+			params = append(params, "38;5;"+word)
+			continue
+		}
+		if isSGR(word) {
+			params = append(params, word)
+			continue
+		}
+		return "", fmt.Errorf("invalid color %q", s)
+	}
+	if len(params) == 0 {
+		return "", fmt.Errorf("invalid color %q", s)
+	}
+	return strings.Join(params, ";"), nil
+}
+
+// isSGR reports whether s looks like raw SGR parameters, e.g. "1;38;5;208".
+func isSGR(s string) bool {
+	if !strings.Contains(s, ";") {
+		return false
+	}
+	for _, p := range strings.Split(s, ";") {
+		if _, err := strconv.ParseUint(p, 10, 8); err != nil {
+			return false
+		}
+	}
+	return true
+}
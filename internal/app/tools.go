@@ -0,0 +1,137 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"gophertube/internal/preview"
+)
+
+// playerConfig is the [player] table of the config file.
+type playerConfig struct {
+	Path        string   `toml:"path"`         // mpv binary
+	Args        []string `toml:"args"`         // added to every mpv run
+	VideoArgs   []string `toml:"video_args"`   // added when watching
+	AudioArgs   []string `toml:"audio_args"`   // added when listening
+	AudioFormat string   `toml:"audio_format"` // yt-dlp format of the audio played
+}
+
+var defaultPlayer = playerConfig{
+	Path:        "mpv",
+	Args:        []string{},
+	VideoArgs:   []string{"--fs"},
+	AudioArgs:   []string{},
+	AudioFormat: "bestaudio[ext=m4a]/bestaudio",
+}
+
+// withDefaults fills in the defaults for unset keys. An empty list is kept,
+// so video_args = [] turns off the default fullscreen.
+func (c playerConfig) withDefaults() playerConfig {
+	c.Path = expandPath(firstNonEmpty(c.Path, defaultPlayer.Path))
+	c.AudioFormat = firstNonEmpty(c.AudioFormat, defaultPlayer.AudioFormat)
+	if c.VideoArgs == nil {
+		c.VideoArgs = defaultPlayer.VideoArgs
+	}
+	return c
+}
+
+// fzfConfig is the [fzf] table of the config file.
+type fzfConfig struct {
+	Path string   `toml:"path"` // fzf binary
+	Args []string `toml:"args"` // added to every fzf run, e.g. ["--layout=reverse"]
+}
+
+var defaultFzf = fzfConfig{Path: "fzf", Args: []string{}}
+
+func (c fzfConfig) withDefaults() fzfConfig {
+	c.Path = expandPath(firstNonEmpty(c.Path, defaultFzf.Path))
+	return c
+}
+
+// ytdlpConfig is the [ytdlp] table of the config file. The preferences
+// shape the formats of heights like "1080p", for watching and downloads.
+type ytdlpConfig struct {
+	Path              string            `toml:"path"`               // yt-dlp binary
+	Args              []string          `toml:"args"`               // added to every download
+	Formats           map[string]string `toml:"formats"`            // quality name to format selector
+	DownloadQualities []string          `toml:"download_qualities"` // offered when downloading
+
+	Fps       int      `toml:"fps"`       // highest framerate, 0 for any
+	Codecs    []string `toml:"codecs"`    // preferred video codecs, best first
+	Container string   `toml:"container"` // preferred container, also what downloads are merged into
+	HDR       string   `toml:"hdr"`       // prefer, avoid or only, empty to let yt-dlp pick
+}
+
+var defaultYtdlp = ytdlpConfig{
+	Path: "yt-dlp",
+	Args: []string{},
+	Formats: map[string]string{
+		"Audio": "bestaudio",
+	},
+	DownloadQualities: []string{"1080p", "720p", "480p", "360p", "Audio"},
+	Codecs:            []string{},
+}
+
+// withDefaults fills in the defaults for unset keys. Formats are merged
+// with the built-in ones so a single quality can be changed or added.
+func (c ytdlpConfig) withDefaults() ytdlpConfig {
+	c.Path = expandPath(firstNonEmpty(c.Path, defaultYtdlp.Path))
+	formats := make(map[string]string, len(defaultYtdlp.Formats)+len(c.Formats))
+	for q, f := range defaultYtdlp.Formats {
+		formats[q] = f
+	}
+	for q, f := range c.Formats {
+		formats[q] = f
+	}
+	c.Formats = formats
+	if c.DownloadQualities == nil {
+		c.DownloadQualities = defaultYtdlp.DownloadQualities
+	}
+	return c
+}
+
+// validate reports empty formats, download qualities that cannot be turned
+// into a format and unknown preferences.
+func (c ytdlpConfig) validate() error {
+	c = c.withDefaults()
+	for q, f := range c.Formats {
+		if f == "" {
+			return fmt.Errorf("ytdlp: empty format for %q", q)
+		}
+	}
+	for _, q := range c.DownloadQualities {
+		if err := c.validQuality(q); err != nil {
+			return fmt.Errorf("ytdlp: download_qualities: %w", err)
+		}
+	}
+	return c.validatePreferences()
+}
+
+// thumbnailsConfig is the [thumbnails] table of the config file.
+type thumbnailsConfig struct {
+	Enabled  *bool  `toml:"enabled"`  // draw thumbnails in the preview
+	Protocol string `toml:"protocol"` // kitty, sixel, iterm2 or blocks, empty to detect
+}
+
+func (c thumbnailsConfig) enabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+func (c thumbnailsConfig) validate() error {
+	if c.Protocol == "" {
+		return nil
+	}
+	if _, ok := preview.ParseProtocol(c.Protocol); !ok {
+		return fmt.Errorf("thumbnails: unknown protocol %q (kitty, sixel, iterm2 or blocks)", c.Protocol)
+	}
+	return nil
+}
+
+// protocolArg is the preview-thumb flag selecting the configured protocol.
+// GOPHERTUBE_IMAGE_PROTOCOL still takes precedence.
+func (c thumbnailsConfig) protocolArg() string {
+	if c.Protocol == "" || os.Getenv(preview.ProtocolEnv) != "" {
+		return ""
+	}
+	return " --protocol=" + shellQuote(c.Protocol)
+}
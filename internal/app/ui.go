@@ -1,28 +1,28 @@
 package app
 
-// Shared UI constants and helpers
+// Shared UI colors and layout, set from the active theme by applyTheme.
+// They are empty when colors are off, so they can always be concatenated.
 
-const (
+var (
 	colorReset   = "\033[0m"
-	colorRed     = "\033[1;31m"
-	colorGreen   = "\033[1;32m"
-	colorYellow  = "\033[1;33m"
-	colorMagenta = "\033[1;35m"
-	colorCyan    = "\033[1;36m"
-	colorWhite   = "\033[1;37m"
+	colorError   = "\033[1;31m"
+	colorSuccess = "\033[1;32m"
+	colorNotice  = "\033[1;33m"
+	colorAccent  = "\033[1;35m"
+	colorInfo    = "\033[1;36m"
+	colorText    = "\033[1;37m"
+	colorMuted   = "\033[0;37m"
 )
 
 // Decorative bar reused in sections
-const barMagenta = "\033[1;35m━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\033[0m"
+var barLine = colorAccent + "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━" + colorReset
 
-// fzf default UI options
-const (
-	fzfBorder         = "rounded"
-	fzfMargin         = "1,1"
-	fzfPreviewWrap    = "wrap"
-	// Thumbnail size ratios relative to preview area
-	previewWidthNum   = 9
-	previewWidthDen   = 10
-	previewHeightNum  = 3
-	previewHeightDen  = 5
+// fzf options and thumbnail size relative to the preview area
+var (
+	fzfColors      = ""
+	fzfBorder      = "rounded"
+	fzfMargin      = "1,1"
+	fzfPreviewWrap = "wrap"
+	previewWidth   = 90 // percent
+	previewHeight  = 60 // percent
 )
@@ -0,0 +1,291 @@
+// Package player runs mpv without a terminal and controls it over its JSON
+// IPC socket, so GopherTube can draw its own now-playing screen and keep
+// playing while the user browses.
+package player
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gophertube/internal/services"
+)
+
+// ErrClosed is returned for commands sent after mpv exited.
+var ErrClosed = errors.New("mpv is not running")
+
+// connectTimeout is how long mpv gets to open its socket.
+const connectTimeout = 5 * time.Second
+
+// Player is a running mpv process.
+type Player struct {
+	cmd    *exec.Cmd
+	socket string
+	conn   net.Conn
+
+	mu      sync.Mutex
+	nextID  int
+	pending map[int]chan response
+
+	done    chan struct{}
+	waitErr error
+}
+
+type response struct {
+	RequestID int             `json:"request_id"`
+	Error     string          `json:"error"`
+	Data      json.RawMessage `json:"data"`
+	Event     string          `json:"event"`
+}
+
+// Status is a snapshot of what mpv is playing.
+type Status struct {
+	Title    string
+	Paused   bool
+	Position time.Duration
+	Duration time.Duration // zero while unknown, e.g. for live streams
+	Volume   float64
+	Speed    float64
+	Index    int // position in the playlist, -1 before the first file loaded
+	Count    int
+}
+
+// Start runs cmd, an mpv command line, with its terminal disabled and an
+// IPC socket, and connects to it. cmd.Cancel still applies, so a context
+// bound command stops mpv with it.
+func Start(cmd *exec.Cmd) (*Player, error) {
+	dir, err := socketDir()
+	if err != nil {
+		return nil, err
+	}
+	socket := filepath.Join(dir, "mpv.sock")
+	cmd.Args = append(cmd.Args, "--no-terminal", "--input-ipc-server="+socket)
+	if err := cmd.Start(); err != nil {
+		os.RemoveAll(dir)
+		return nil, err
+	}
+	p := &Player{
+		cmd:     cmd,
+		socket:  socket,
+		pending: make(map[int]chan response),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		p.waitErr = cmd.Wait()
+		os.RemoveAll(dir)
+		close(p.done)
+	}()
+
+	deadline := time.Now().Add(connectTimeout)
+	for {
+		conn, err := net.Dial("unix", socket)
+		if err == nil {
+			p.conn = conn
+			break
+		}
+		select {
+		case <-p.done:
+			return nil, fmt.Errorf("mpv exited: %v", p.waitErr)
+		case <-time.After(50 * time.Millisecond):
+		}
+		if time.Now().After(deadline) {
+			cmd.Process.Kill()
+			<-p.done
+			return nil, fmt.Errorf("mpv did not open %s", socket)
+		}
+	}
+	go p.read()
+	return p, nil
+}
+
+// socketDir creates a directory only the user can enter for the IPC
+// socket, so no one else can connect to mpv or take its place: in
+// $XDG_RUNTIME_DIR, or in the cache directory where there is none.
+func socketDir() (string, error) {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); filepath.IsAbs(dir) {
+		return os.MkdirTemp(dir, "gophertube-mpv-")
+	}
+	dir := services.DefaultCacheDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return os.MkdirTemp(dir, "mpv-")
+}
+
+// read hands every reply to the command waiting for it. Events are not
+// needed, Status is polled instead.
+func (p *Player) read() {
+	sc := bufio.NewScanner(p.conn)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		var r response
+		if json.Unmarshal(sc.Bytes(), &r) != nil || r.Event != "" {
+			continue
+		}
+		p.mu.Lock()
+		ch := p.pending[r.RequestID]
+		delete(p.pending, r.RequestID)
+		p.mu.Unlock()
+		if ch != nil {
+			ch <- r
+		}
+	}
+	p.mu.Lock()
+	for id, ch := range p.pending {
+		close(ch)
+		delete(p.pending, id)
+	}
+	p.conn.Close()
+	p.mu.Unlock()
+}
+
+// Command runs an mpv input command such as "seek", 10 and returns its
+// data.
+func (p *Player) Command(args ...any) (json.RawMessage, error) {
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	ch := make(chan response, 1)
+	p.pending[id] = ch
+	msg, _ := json.Marshal(map[string]any{"command": args, "request_id": id})
+	_, err := p.conn.Write(append(msg, '\n'))
+	if err != nil {
+		delete(p.pending, id)
+	}
+	p.mu.Unlock()
+	if err != nil {
+		return nil, ErrClosed
+	}
+
+	select {
+	case r, ok := <-ch:
+		if !ok {
+			return nil, ErrClosed
+		}
+		if r.Error != "success" {
+			return nil, fmt.Errorf("mpv %v: %s", args[0], r.Error)
+		}
+		return r.Data, nil
+	case <-p.done:
+		return nil, ErrClosed
+	}
+}
+
+// get decodes property name into v, leaving v alone when the property is
+// not available yet.
+func (p *Player) get(name string, v any) {
+	if data, err := p.Command("get_property", name); err == nil {
+		json.Unmarshal(data, v)
+	}
+}
+
+// Status reads the properties shown on the now-playing screen.
+func (p *Player) Status() (Status, error) {
+	if !p.Running() {
+		return Status{}, ErrClosed
+	}
+	s := Status{Index: -1, Volume: 100, Speed: 1}
+	var pos, duration float64
+	p.get("media-title", &s.Title)
+	p.get("pause", &s.Paused)
+	p.get("time-pos", &pos)
+	p.get("duration", &duration)
+	p.get("volume", &s.Volume)
+	p.get("speed", &s.Speed)
+	p.get("playlist-pos", &s.Index)
+	p.get("playlist-count", &s.Count)
+	s.Position = time.Duration(pos * float64(time.Second))
+	s.Duration = time.Duration(duration * float64(time.Second))
+	return s, nil
+}
+
+// TogglePause pauses or resumes playback.
+func (p *Player) TogglePause() error {
+	_, err := p.Command("cycle", "pause")
+	return err
+}
+
+// SetPaused pauses or resumes playback.
+func (p *Player) SetPaused(paused bool) error {
+	_, err := p.Command("set_property", "pause", paused)
+	return err
+}
+
+// Append adds url to the end of the playlist.
+func (p *Player) Append(url string) error {
+	_, err := p.Command("loadfile", url, "append")
+	return err
+}
+
+// Seek moves by d, backwards when negative.
+func (p *Player) Seek(d time.Duration) error {
+	_, err := p.Command("seek", d.Seconds(), "relative")
+	return err
+}
+
+// Next skips to the next entry of the playlist.
+func (p *Player) Next() error {
+	_, err := p.Command("playlist-next")
+	return err
+}
+
+// Prev goes back to the previous entry of the playlist.
+func (p *Player) Prev() error {
+	_, err := p.Command("playlist-prev")
+	return err
+}
+
+// AddVolume changes the volume by delta percent.
+func (p *Player) AddVolume(delta float64) error {
+	_, err := p.Command("add", "volume", delta)
+	return err
+}
+
+// MultiplySpeed changes the playback speed by factor, 0 resets it.
+func (p *Player) MultiplySpeed(factor float64) error {
+	if factor == 0 {
+		_, err := p.Command("set_property", "speed", 1.0)
+		return err
+	}
+	_, err := p.Command("multiply", "speed", factor)
+	return err
+}
+
+// Running reports whether mpv is still running.
+func (p *Player) Running() bool {
+	select {
+	case <-p.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// Done is closed once mpv has exited.
+func (p *Player) Done() <-chan struct{} {
+	return p.done
+}
+
+// Err returns why mpv exited, nil after a normal end or quit. It is only
+// meaningful once Done is closed.
+func (p *Player) Err() error {
+	return p.waitErr
+}
+
+// Quit asks mpv to exit and waits for it, killing it if it does not.
+func (p *Player) Quit() {
+	go p.Command("quit")
+	select {
+	case <-p.done:
+	case <-time.After(3 * time.Second):
+		p.cmd.Process.Kill()
+		<-p.done
+	}
+}
@@ -0,0 +1,59 @@
+package preview
+
+import (
+	"os"
+	"strings"
+)
+
+// Protocol is a way of drawing an image in a terminal.
+type Protocol string
+
+const (
+	ProtocolKitty  Protocol = "kitty"
+	ProtocolSixel  Protocol = "sixel"
+	ProtocolITerm2 Protocol = "iterm2"
+	ProtocolBlocks Protocol = "blocks"
+)
+
+// ProtocolEnv overrides the detected protocol when set to one of the
+// Protocol values.
+const ProtocolEnv = "GOPHERTUBE_IMAGE_PROTOCOL"
+
+// ParseProtocol validates a protocol name.
+func ParseProtocol(s string) (Protocol, bool) {
+	switch p := Protocol(strings.ToLower(strings.TrimSpace(s))); p {
+	case ProtocolKitty, ProtocolSixel, ProtocolITerm2, ProtocolBlocks:
+		return p, true
+	}
+	return "", false
+}
+
+// Detect guesses the best protocol from the environment. The preview runs
+// inside fzf without access to the terminal, so querying it is not an
+// option and environment variables set by the emulators are all we have.
+func Detect() Protocol {
+	if p, ok := ParseProtocol(os.Getenv(ProtocolEnv)); ok {
+		return p
+	}
+
+	term := strings.ToLower(os.Getenv("TERM"))
+	prog := strings.ToLower(os.Getenv("TERM_PROGRAM"))
+
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "", strings.Contains(term, "kitty"),
+		strings.Contains(term, "ghostty"), prog == "ghostty":
+		return ProtocolKitty
+	case prog == "iterm.app", prog == "wezterm", os.Getenv("LC_TERMINAL") == "iTerm2":
+		return ProtocolITerm2
+	case strings.HasPrefix(term, "foot"), strings.Contains(term, "mlterm"),
+		strings.Contains(term, "sixel"), os.Getenv("KONSOLE_VERSION") != "":
+		return ProtocolSixel
+	}
+	return ProtocolBlocks
+}
+
+// trueColor reports whether 24-bit escape sequences can be used.
+func trueColor() bool {
+	ct := strings.ToLower(os.Getenv("COLORTERM"))
+	return ct == "truecolor" || ct == "24bit"
+}
@@ -0,0 +1,236 @@
+// Package preview draws thumbnails in the terminal without external tools.
+// It backs the hidden `gophertube preview-thumb` command that fzf runs for
+// every highlighted result.
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"os"
+
+	xdraw "golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// Assumed size of a terminal cell in pixels. The preview cannot ask the
+// terminal, and 1:2 is what nearly every monospace font ends up at.
+const (
+	cellWidth  = 10
+	cellHeight = 20
+)
+
+// kitty limits a single graphics escape to 4096 bytes of payload.
+const kittyChunk = 4096
+
+// Render decodes the image at path and draws it with p, fitted into a box of
+// cols x rows terminal cells while keeping its aspect ratio.
+func Render(w io.Writer, path string, cols, rows int, p Protocol) error {
+	if cols <= 0 || rows <= 0 {
+		return fmt.Errorf("invalid preview size %dx%d", cols, rows)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	switch p {
+	case ProtocolKitty:
+		err = writeKitty(bw, img, cols, rows)
+	case ProtocolITerm2:
+		err = writeITerm2(bw, img, cols, rows)
+	case ProtocolSixel:
+		err = writeSixel(bw, img, cols, rows)
+	default:
+		err = writeBlocks(bw, img, cols, rows)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// fit returns the size in pixels img should be drawn at to fill a box of
+// boxW x boxH pixels without distortion.
+func fit(b image.Rectangle, boxW, boxH int) (int, int) {
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return 0, 0
+	}
+	if w*boxH > h*boxW {
+		return boxW, max(1, h*boxW/w)
+	}
+	return max(1, w*boxH/h), boxH
+}
+
+// fitCells is fit expressed in terminal cells, rounding up.
+func fitCells(b image.Rectangle, cols, rows int) (int, int) {
+	pw, ph := fit(b, cols*cellWidth, rows*cellHeight)
+	return max(1, (pw+cellWidth-1)/cellWidth), max(1, (ph+cellHeight-1)/cellHeight)
+}
+
+func scale(img image.Image, w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, img.Bounds(), xdraw.Src, nil)
+	return dst
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeKitty uses the kitty graphics protocol and lets the terminal do the
+// scaling to the requested cell area.
+func writeKitty(w *bufio.Writer, img image.Image, cols, rows int) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+	c, r := fitCells(img.Bounds(), cols, rows)
+	payload := base64.StdEncoding.EncodeToString(data)
+	for i := 0; i < len(payload); i += kittyChunk {
+		end := min(i+kittyChunk, len(payload))
+		more := 0
+		if end < len(payload) {
+			more = 1
+		}
+		if i == 0 {
+			fmt.Fprintf(w, "\x1b_Ga=T,f=100,q=2,c=%d,r=%d,m=%d;%s\x1b\\", c, r, more, payload[i:end])
+		} else {
+			fmt.Fprintf(w, "\x1b_Gm=%d;%s\x1b\\", more, payload[i:end])
+		}
+	}
+	_, err = w.WriteString("\n")
+	return err
+}
+
+// writeITerm2 uses the inline image protocol of iTerm2, also understood by
+// WezTerm.
+func writeITerm2(w *bufio.Writer, img image.Image, cols, rows int) error {
+	data, err := encodePNG(img)
+	if err != nil {
+		return err
+	}
+	c, r := fitCells(img.Bounds(), cols, rows)
+	fmt.Fprintf(w, "\x1b]1337;File=inline=1;size=%d;width=%d;height=%d;preserveAspectRatio=1:%s\a\n",
+		len(data), c, r, base64.StdEncoding.EncodeToString(data))
+	return nil
+}
+
+// writeSixel quantizes the image to a 256 color palette and encodes it as
+// sixel bands of six pixel rows each.
+func writeSixel(w *bufio.Writer, img image.Image, cols, rows int) error {
+	pw, ph := fit(img.Bounds(), cols*cellWidth, rows*cellHeight)
+	src := scale(img, pw, ph)
+	pal := image.NewPaletted(src.Bounds(), palette.Plan9)
+	draw.FloydSteinberg.Draw(pal, pal.Bounds(), src, image.Point{})
+
+	fmt.Fprintf(w, "\x1bPq\"1;1;%d;%d", pw, ph)
+	for i, c := range pal.Palette {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(w, "#%d;2;%d;%d;%d", i, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	used := make([]bool, len(pal.Palette))
+	for y0 := 0; y0 < ph; y0 += 6 {
+		clear(used)
+		for y := y0; y < min(y0+6, ph); y++ {
+			for x := 0; x < pw; x++ {
+				used[pal.ColorIndexAt(x, y)] = true
+			}
+		}
+		first := true
+		for ci, ok := range used {
+			if !ok {
+				continue
+			}
+			if !first {
+				w.WriteByte('$') // back to the start of the band
+			}
+			first = false
+			fmt.Fprintf(w, "#%d", ci)
+			var prev byte
+			run := 0
+			for x := 0; x < pw; x++ {
+				var bits byte
+				for dy := 0; dy < 6 && y0+dy < ph; dy++ {
+					if int(pal.ColorIndexAt(x, y0+dy)) == ci {
+						bits |= 1 << dy
+					}
+				}
+				ch := 63 + bits
+				if ch == prev {
+					run++
+					continue
+				}
+				writeSixelRun(w, prev, run)
+				prev, run = ch, 1
+			}
+			writeSixelRun(w, prev, run)
+		}
+		w.WriteByte('-')
+	}
+	_, err := w.WriteString("\x1b\\\n")
+	return err
+}
+
+func writeSixelRun(w *bufio.Writer, ch byte, run int) {
+	switch {
+	case run == 0:
+	case run > 3:
+		fmt.Fprintf(w, "!%d%c", run, ch)
+	default:
+		for i := 0; i < run; i++ {
+			w.WriteByte(ch)
+		}
+	}
+}
+
+// writeBlocks draws two pixels per cell with the upper half block character,
+// which works in any terminal with color support.
+func writeBlocks(w *bufio.Writer, img image.Image, cols, rows int) error {
+	pw, ph := fit(img.Bounds(), cols, rows*2)
+	src := scale(img, pw, ph)
+	tc := trueColor()
+	for y := 0; y < ph; y += 2 {
+		for x := 0; x < pw; x++ {
+			top := src.RGBAAt(x, y)
+			bottom := top
+			if y+1 < ph {
+				bottom = src.RGBAAt(x, y+1)
+			}
+			if tc {
+				fmt.Fprintf(w, "\x1b[38;2;%d;%d;%dm\x1b[48;2;%d;%d;%dm▀",
+					top.R, top.G, top.B, bottom.R, bottom.G, bottom.B)
+			} else {
+				fmt.Fprintf(w, "\x1b[38;5;%dm\x1b[48;5;%dm▀",
+					ansi256(top.R, top.G, top.B), ansi256(bottom.R, bottom.G, bottom.B))
+			}
+		}
+		w.WriteString("\x1b[0m\n")
+	}
+	return nil
+}
+
+// ansi256 maps a color onto the 6x6x6 cube of the 256 color palette.
+func ansi256(r, g, b uint8) int {
+	q := func(v uint8) int { return (int(v)*5 + 127) / 255 }
+	return 16 + 36*q(r) + 6*q(g) + q(b)
+}
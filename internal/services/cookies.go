@@ -0,0 +1,238 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CookieConfig selects where the cookies sent to YouTube come from. At most
+// one of the fields is set.
+type CookieConfig struct {
+	File    string // Netscape cookies.txt
+	Browser string // yt-dlp --cookies-from-browser spec, e.g. "firefox" or "chrome:Profile 1"
+}
+
+// cookieJar loads its cookies on the first request to YouTube, exporting
+// them from a browser is slow and not needed by every command.
+type cookieJar struct {
+	config CookieConfig
+
+	mu     sync.Mutex
+	loaded bool
+	jar    *cookiejar.Jar
+	err    error
+}
+
+var cookies = newCookieJar(CookieConfig{})
+
+// ytDlpPath is the yt-dlp binary used to export browser cookies.
+var ytDlpPath = "yt-dlp"
+
+// SetYtDlpPath selects the yt-dlp binary, a name on PATH or a path.
+func SetYtDlpPath(path string) {
+	ytDlpPath = path
+}
+
+func newCookieJar(c CookieConfig) *cookieJar {
+	return &cookieJar{config: c}
+}
+
+// SetCookies selects the cookie source. A cookies file is read right away
+// so that mistakes are reported at startup.
+func SetCookies(c CookieConfig) error {
+	if err := CheckCookies(c); err != nil {
+		return err
+	}
+	cookies = newCookieJar(c)
+	httpClient.Jar = cookies
+	return nil
+}
+
+// CheckCookies reports what SetCookies would reject in c.
+func CheckCookies(c CookieConfig) error {
+	if c.File != "" && c.Browser != "" {
+		return fmt.Errorf("cookies: use either a cookies file or a browser, not both")
+	}
+	if c.File != "" {
+		if _, err := readCookieFile(c.File); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Cookies returns the active cookie source.
+func Cookies() CookieConfig {
+	return cookies.config
+}
+
+func isYouTubeHost(host string) bool {
+	return host == "youtube.com" || strings.HasSuffix(host, ".youtube.com")
+}
+
+// load fills the jar and adds the consent cookie when none is present. An
+// export interrupted by ctx is tried again on the next request.
+func (c *cookieJar) load(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.loaded {
+		return c.err
+	}
+	c.jar, _ = cookiejar.New(nil)
+	var list []fileCookie
+	switch {
+	case c.config.File != "":
+		list, c.err = readCookieFile(c.config.File)
+	case c.config.Browser != "":
+		list, c.err = exportBrowserCookies(ctx, c.config.Browser)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	for _, fc := range list {
+		c.jar.SetCookies(fc.url, []*http.Cookie{fc.cookie})
+	}
+	addConsentCookie(c.jar)
+	c.loaded = true
+	return c.err
+}
+
+// Cookies implements http.CookieJar.
+func (c *cookieJar) Cookies(u *url.URL) []*http.Cookie {
+	if !isYouTubeHost(u.Hostname()) {
+		return nil
+	}
+	c.load(context.Background())
+	return c.jar.Cookies(u)
+}
+
+// SetCookies implements http.CookieJar.
+func (c *cookieJar) SetCookies(u *url.URL, cs []*http.Cookie) {
+	if !isYouTubeHost(u.Hostname()) {
+		return
+	}
+	c.load(context.Background())
+	c.jar.SetCookies(u, cs)
+}
+
+var youtubeURL = &url.URL{Scheme: "https", Host: "www.youtube.com", Path: "/"}
+
+// addConsentCookie answers the EU consent dialog the way yt-dlp does, by
+// rejecting everything optional. Without it requests from the EU are
+// redirected to consent.youtube.com.
+func addConsentCookie(jar *cookiejar.Jar) {
+	for _, c := range jar.Cookies(youtubeURL) {
+		if c.Name == "SOCS" || (c.Name == "CONSENT" && strings.HasPrefix(c.Value, "YES")) {
+			return
+		}
+	}
+	jar.SetCookies(youtubeURL, []*http.Cookie{{
+		Name:    "SOCS",
+		Value:   "CAI",
+		Domain:  ".youtube.com",
+		Path:    "/",
+		Secure:  true,
+		Expires: time.Now().AddDate(1, 0, 0),
+	}})
+}
+
+type fileCookie struct {
+	url    *url.URL
+	cookie *http.Cookie
+}
+
+// readCookieFile parses a Netscape cookies.txt as written by browser
+// extensions, curl and yt-dlp.
+func readCookieFile(path string) ([]fileCookie, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cookies: %w", err)
+	}
+	defer f.Close()
+
+	var list []fileCookie
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimRight(sc.Text(), "\r")
+		httpOnly := false
+		if rest, ok := strings.CutPrefix(line, "#HttpOnly_"); ok {
+			line, httpOnly = rest, true
+		}
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("cookies: %s:%d: expected 7 tab separated fields, got %d", path, n, len(fields))
+		}
+		domain, subdomains, cpath, secure, expires, name, value := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+		exp, err := strconv.ParseInt(expires, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("cookies: %s:%d: invalid expiry %q", path, n, expires)
+		}
+
+		c := &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     cpath,
+			Secure:   strings.EqualFold(secure, "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		if exp > 0 {
+			c.Expires = time.Unix(exp, 0)
+		}
+		// A host-only cookie has no Domain attribute
+		if strings.EqualFold(subdomains, "TRUE") {
+			c.Domain = domain
+		}
+		scheme := "http"
+		if c.Secure {
+			scheme = "https"
+		}
+		host := strings.TrimPrefix(domain, ".")
+		list = append(list, fileCookie{&url.URL{Scheme: scheme, Host: host, Path: cpath}, c})
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("cookies: %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// exportBrowserCookies has yt-dlp decrypt the cookies of a browser profile
+// into a private directory that is removed as soon as the file is read, the
+// decrypted cookies are as good as the user's logins.
+func exportBrowserCookies(ctx context.Context, browser string) ([]fileCookie, error) {
+	dir, err := os.MkdirTemp("", "gophertube-cookies-")
+	if err != nil {
+		return nil, fmt.Errorf("cookies: %w", err)
+	}
+	path := filepath.Join(dir, "cookies.txt")
+
+	// yt-dlp saves the jar on exit even though it complains about the
+	// missing URL, so only the file tells whether the export worked.
+	out, _ := exec.CommandContext(ctx, ytDlpPath, "--cookies-from-browser", browser, "--cookies", path).CombinedOutput()
+	list, err := readCookieFile(path)
+	os.RemoveAll(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = "is yt-dlp installed?"
+		}
+		return nil, fmt.Errorf("cookies from %s: %s", browser, msg)
+	}
+	return list, err
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeCookieFile(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadCookieFile(t *testing.T) {
+	path := writeCookieFile(t,
+		"# Netscape HTTP Cookie File",
+		"",
+		".youtube.com\tTRUE\t/\tTRUE\t1893456000\tPREF\tf6=40000000",
+		"#HttpOnly_.youtube.com\tTRUE\t/\tTRUE\t0\tLOGIN_INFO\tsecret",
+		"www.youtube.com\tFALSE\t/feed\tFALSE\t0\tHOST\tonly\r",
+	)
+	list, err := readCookieFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 {
+		t.Fatalf("got %d cookies, want 3", len(list))
+	}
+
+	tests := []struct {
+		name, url, domain, path string
+		secure, httpOnly        bool
+		expires                 time.Time
+		value                   string
+	}{
+		{"PREF", "https://youtube.com/", ".youtube.com", "/", true, false, time.Unix(1893456000, 0), "f6=40000000"},
+		{"LOGIN_INFO", "https://youtube.com/", ".youtube.com", "/", true, true, time.Time{}, "secret"},
+		{"HOST", "http://www.youtube.com/feed", "", "/feed", false, false, time.Time{}, "only"},
+	}
+	for i, tt := range tests {
+		fc := list[i]
+		c := fc.cookie
+		if c.Name != tt.name || c.Value != tt.value {
+			t.Errorf("cookie %d = %s=%s, want %s=%s", i, c.Name, c.Value, tt.name, tt.value)
+		}
+		if got := fc.url.String(); got != tt.url {
+			t.Errorf("%s: url = %s, want %s", tt.name, got, tt.url)
+		}
+		if c.Domain != tt.domain {
+			t.Errorf("%s: domain = %q, want %q", tt.name, c.Domain, tt.domain)
+		}
+		if c.Path != tt.path {
+			t.Errorf("%s: path = %q, want %q", tt.name, c.Path, tt.path)
+		}
+		if c.Secure != tt.secure || c.HttpOnly != tt.httpOnly {
+			t.Errorf("%s: secure, httpOnly = %v, %v, want %v, %v", tt.name, c.Secure, c.HttpOnly, tt.secure, tt.httpOnly)
+		}
+		if !c.Expires.Equal(tt.expires) {
+			t.Errorf("%s: expires = %v, want %v", tt.name, c.Expires, tt.expires)
+		}
+	}
+}
+
+func TestReadCookieFileMalformed(t *testing.T) {
+	tests := []struct {
+		line, want string
+	}{
+		{".youtube.com\tTRUE\t/\tTRUE\t0\tPREF", "expected 7 tab separated fields, got 6"},
+		{".youtube.com TRUE / TRUE 0 PREF x", "expected 7 tab separated fields, got 1"},
+		{".youtube.com\tTRUE\t/\tTRUE\tsoon\tPREF\tx", `invalid expiry "soon"`},
+		{"#HttpOnly_.youtube.com\tTRUE\t/\tTRUE\t0\tSID", "expected 7 tab separated fields, got 6"},
+	}
+	for _, tt := range tests {
+		path := writeCookieFile(t, "# Netscape HTTP Cookie File", tt.line)
+		_, err := readCookieFile(path)
+		if err == nil {
+			t.Errorf("%q: no error", tt.line)
+			continue
+		}
+		if !strings.Contains(err.Error(), ":2: "+tt.want) {
+			t.Errorf("%q: error %q, want line 2 and %q", tt.line, err, tt.want)
+		}
+	}
+}
+
+func TestReadCookieFileMissing(t *testing.T) {
+	if _, err := readCookieFile(filepath.Join(t.TempDir(), "none.txt")); err == nil {
+		t.Error("no error for a missing file")
+	}
+}
+
+func TestExportBrowserCookiesRemovesFile(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "yt-dlp")
+	script := "#!/bin/sh\n" +
+		"while [ \"$1\" != --cookies ]; do shift; done\n" +
+		"printf '.youtube.com\\tTRUE\\t/\\tTRUE\\t0\\tSID\\tx\\n' > \"$2\"\n" +
+		"echo \"$2\" > \"$(dirname \"$0\")/path\"\n"
+	if err := os.WriteFile(bin, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	defer SetYtDlpPath(ytDlpPath)
+	SetYtDlpPath(bin)
+
+	list, err := exportBrowserCookies(context.Background(), "firefox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].cookie.Name != "SID" {
+		t.Fatalf("got %v, want the SID cookie", list)
+	}
+	written, err := os.ReadFile(filepath.Join(filepath.Dir(bin), "path"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := filepath.Dir(strings.TrimSpace(string(written)))
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("export directory %s still exists", dir)
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// xdgDir is $env/gophertube when env holds an absolute path, as the XDG
+// base directory spec asks, and ~/fallback/gophertube otherwise.
+func xdgDir(env string, fallback ...string) string {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return filepath.Join(dir, "gophertube")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), fmt.Sprintf("gophertube-%d", os.Getuid()))
+	}
+	return filepath.Join(append(append([]string{home}, fallback...), "gophertube")...)
+}
+
+// DefaultConfigDir is $XDG_CONFIG_HOME/gophertube, falling back to
+// ~/.config/gophertube.
+func DefaultConfigDir() string {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// DefaultDataDir is $XDG_DATA_HOME/gophertube, falling back to
+// ~/.local/share/gophertube. It holds the history and the queue.
+func DefaultDataDir() string {
+	return xdgDir("XDG_DATA_HOME", ".local", "share")
+}
+
+// DefaultCacheDir is $XDG_CACHE_HOME/gophertube, falling back to
+// ~/.cache/gophertube. Everything in it can be deleted at any time.
+func DefaultCacheDir() string {
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// Migration is a directory moved from where older versions kept it.
+type Migration struct {
+	From, To string
+}
+
+// MigrateLegacyDirs moves the config and cache directories of older versions
+// to the XDG locations. Older versions always read the config from ~/.config
+// and put the cache where the OS keeps caches, e.g. ~/Library/Caches on
+// macOS; the data directory was already XDG. A directory is only moved when
+// nothing exists at the new location yet, so this happens once.
+func MigrateLegacyDirs() ([]Migration, error) {
+	var legacy []Migration
+	if home, err := os.UserHomeDir(); err == nil {
+		legacy = append(legacy, Migration{filepath.Join(home, ".config", "gophertube"), DefaultConfigDir()})
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		legacy = append(legacy, Migration{filepath.Join(dir, "gophertube"), DefaultCacheDir()})
+	}
+
+	var done []Migration
+	for _, m := range legacy {
+		if m.From == m.To || !exists(m.From) || exists(m.To) {
+			continue
+		}
+		if err := moveDir(m.From, m.To); err != nil {
+			return done, fmt.Errorf("moving %s to %s: %w", m.From, m.To, err)
+		}
+		done = append(done, m)
+	}
+	return done, nil
+}
+
+func exists(path string) bool {
+	_, err := os.Lstat(path)
+	return !errors.Is(err, fs.ErrNotExist)
+}
+
+// moveDir renames from to to, copying across file systems.
+func moveDir(from, to string) error {
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	if os.Rename(from, to) == nil {
+		return nil
+	}
+	err := filepath.WalkDir(from, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		dst := filepath.Join(to, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return os.MkdirAll(dst, info.Mode().Perm())
+		case d.Type().IsRegular():
+			return copyFile(path, dst, info.Mode().Perm())
+		}
+		return nil // sockets and links are not worth keeping
+	})
+	if err != nil {
+		os.RemoveAll(to) // try again next time
+		return err
+	}
+	return os.RemoveAll(from)
+}
+
+func copyFile(from, to string, perm fs.FileMode) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(to, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
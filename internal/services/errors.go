@@ -0,0 +1,149 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Errors returned by SearchYouTube. They are wrapped together with the
+// underlying cause, test for them with errors.Is.
+var (
+	// ErrOffline is returned when a result is needed from the network while
+	// offline mode is active and no cached copy exists.
+	ErrOffline = errors.New("not available offline")
+	// ErrNetwork means YouTube could not be reached at all.
+	ErrNetwork = errors.New("network error")
+	// ErrConsent means YouTube answered with its cookie consent page.
+	ErrConsent = errors.New("cookie consent required")
+	// ErrRateLimited means YouTube throttled the requests or asked to solve
+	// a captcha.
+	ErrRateLimited = errors.New("rate limited by YouTube")
+	// ErrCaptcha is the kind of rate limiting that only a human can lift,
+	// it matches ErrRateLimited as well.
+	ErrCaptcha = fmt.Errorf("%w: captcha required", ErrRateLimited)
+	// ErrLayoutChanged means the page arrived but could not be parsed,
+	// usually because YouTube changed its markup.
+	ErrLayoutChanged = errors.New("unrecognized page layout")
+	// ErrNoResults means YouTube has nothing for the query.
+	ErrNoResults = errors.New("no results")
+)
+
+// StatusError is returned for responses with an unexpected status code.
+type StatusError struct {
+	URL        string
+	Code       int
+	RetryAfter time.Duration // from the Retry-After header, 0 if absent
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("GET %s: HTTP %d %s", e.URL, e.Code, http.StatusText(e.Code))
+}
+
+// Markers of pages served instead of the results.
+var (
+	consentMarkers = []string{"consent.youtube.com", "consent.google.com"}
+	captchaMarkers = []string{"g-recaptcha", "/sorry/index", "unusual traffic"}
+)
+
+// classifyResponse checks the status and where a request ended up after
+// following redirects.
+func classifyResponse(resp *http.Response) error {
+	u := resp.Request.URL
+	se := &StatusError{URL: u.String(), Code: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	switch {
+	case strings.HasPrefix(u.Path, "/sorry"):
+		return ErrCaptcha
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return fmt.Errorf("%w: %w", ErrRateLimited, se)
+	case strings.HasPrefix(u.Hostname(), "consent."):
+		return ErrConsent
+	case resp.StatusCode != http.StatusOK:
+		return se
+	}
+	return nil
+}
+
+// parseRetryAfter reads the header in either of its forms, seconds or an
+// HTTP date.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// classifyPage explains why no ytInitialData was found in body.
+func classifyPage(body []byte) error {
+	page := string(body)
+	for _, m := range captchaMarkers {
+		if strings.Contains(page, m) {
+			return ErrCaptcha
+		}
+	}
+	for _, m := range consentMarkers {
+		if strings.Contains(page, m) {
+			return ErrConsent
+		}
+	}
+	return fmt.Errorf("%w: ytInitialData not found", ErrLayoutChanged)
+}
+
+// debugDir receives a copy of every page scraped from YouTube, empty
+// disables dumping.
+var (
+	debugDir string
+	lastDump string
+)
+
+// SetDebugDir enables dumping raw responses into dir.
+func SetDebugDir(dir string) {
+	debugDir = dir
+}
+
+// DefaultDebugDir is $XDG_CACHE_HOME/gophertube/debug.
+func DefaultDebugDir() string {
+	return filepath.Join(DefaultCacheDir(), "debug")
+}
+
+// LastDump returns the file the most recent response was saved to, if
+// debugging is enabled.
+func LastDump() string {
+	return lastDump
+}
+
+// dumpResponse writes the status line, headers and body of resp to a new
+// file in debugDir.
+func dumpResponse(resp *http.Response, body []byte) {
+	if debugDir == "" {
+		return
+	}
+	if err := os.MkdirAll(debugDir, 0o700); err != nil {
+		return
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "GET %s\n%s %s\n", resp.Request.URL, resp.Proto, resp.Status)
+	resp.Header.Write(&b)
+	b.WriteString("\n")
+	b.Write(body)
+
+	f, err := os.CreateTemp(debugDir, "response-"+time.Now().Format("20060102-150405")+"-*.txt")
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	if _, err := f.WriteString(b.String()); err == nil {
+		lastDump = f.Name()
+	}
+}
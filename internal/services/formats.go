@@ -0,0 +1,140 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// Format is one stream yt-dlp can fetch for a video.
+type Format struct {
+	ID           string
+	Ext          string
+	Width        int
+	Height       int
+	Fps          float64
+	VideoCodec   string // empty for audio-only streams
+	AudioCodec   string // empty for video-only streams
+	Bitrate      float64
+	Size         int64 // bytes, estimated from the bitrate when yt-dlp does not know
+	DynamicRange string
+	Note         string
+}
+
+// HasVideo reports whether the stream has a picture.
+func (f Format) HasVideo() bool {
+	return f.VideoCodec != ""
+}
+
+// HasAudio reports whether the stream has sound.
+func (f Format) HasAudio() bool {
+	return f.AudioCodec != ""
+}
+
+// ytdlpFormat is the part of a format in yt-dlp's JSON used here.
+type ytdlpFormat struct {
+	ID             string  `json:"format_id"`
+	Ext            string  `json:"ext"`
+	Width          int     `json:"width"`
+	Height         int     `json:"height"`
+	Fps            float64 `json:"fps"`
+	VCodec         string  `json:"vcodec"`
+	ACodec         string  `json:"acodec"`
+	TBR            float64 `json:"tbr"`
+	Filesize       int64   `json:"filesize"`
+	FilesizeApprox int64   `json:"filesize_approx"`
+	DynamicRange   string  `json:"dynamic_range"`
+	Note           string  `json:"format_note"`
+	Protocol       string  `json:"protocol"`
+}
+
+// VideoFormats asks yt-dlp which streams url offers, best video first and
+// audio-only streams last.
+func VideoFormats(ctx context.Context, url string) ([]Format, error) {
+	if offline {
+		return nil, ErrOffline
+	}
+	args := append(YtDlpArgs(), "-J", "--no-playlist", "--no-warnings", url)
+	var stderr bytes.Buffer
+	c := exec.CommandContext(ctx, ytDlpPath, args...)
+	c.Stderr = &stderr
+	out, err := c.Output()
+	if err != nil {
+		if msg := lastLine(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("yt-dlp: %s", msg)
+		}
+		return nil, fmt.Errorf("yt-dlp: %w", err)
+	}
+
+	var info struct {
+		Duration float64       `json:"duration"`
+		Formats  []ytdlpFormat `json:"formats"`
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, fmt.Errorf("yt-dlp: %w", err)
+	}
+	formats := make([]Format, 0, len(info.Formats))
+	for _, yf := range info.Formats {
+		f := Format{
+			ID:           yf.ID,
+			Ext:          yf.Ext,
+			Width:        yf.Width,
+			Height:       yf.Height,
+			Fps:          yf.Fps,
+			VideoCodec:   codecOrEmpty(yf.VCodec),
+			AudioCodec:   codecOrEmpty(yf.ACodec),
+			Bitrate:      yf.TBR,
+			Size:         yf.Filesize,
+			DynamicRange: yf.DynamicRange,
+			Note:         yf.Note,
+		}
+		// Storyboards are images, not something to watch
+		if !f.HasVideo() && !f.HasAudio() || yf.Protocol == "mhtml" {
+			continue
+		}
+		if f.Size == 0 {
+			f.Size = yf.FilesizeApprox
+		}
+		if f.Size == 0 && f.Bitrate > 0 {
+			f.Size = int64(f.Bitrate * 1000 / 8 * info.Duration) // tbr is in kbit/s
+		}
+		formats = append(formats, f)
+	}
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("yt-dlp: no formats found")
+	}
+
+	sort.SliceStable(formats, func(i, j int) bool {
+		a, b := formats[i], formats[j]
+		if a.HasVideo() != b.HasVideo() {
+			return a.HasVideo()
+		}
+		if a.Height != b.Height {
+			return a.Height > b.Height
+		}
+		if a.Fps != b.Fps {
+			return a.Fps > b.Fps
+		}
+		return a.Bitrate > b.Bitrate
+	})
+	return formats, nil
+}
+
+// codecOrEmpty maps yt-dlp's "none" for a missing stream to "".
+func codecOrEmpty(codec string) string {
+	if codec == "none" {
+		return ""
+	}
+	return codec
+}
+
+// lastLine returns the last non-empty line of s, where yt-dlp puts the
+// error.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
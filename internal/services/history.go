@@ -0,0 +1,79 @@
+package services
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gophertube/internal/types"
+)
+
+// HistoryEntry is one watched or listened video.
+type HistoryEntry struct {
+	Video   types.Video
+	Watched time.Time
+}
+
+func historyPath() string {
+	return filepath.Join(DefaultDataDir(), "history.jsonl")
+}
+
+// AppendHistory records v as watched now.
+func AppendHistory(v types.Video) error {
+	v.ThumbnailPath = "" // depends on the cache, resolved again when loaded
+	data, err := json.Marshal(HistoryEntry{Video: v, Watched: time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(DefaultDataDir(), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(historyPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadHistory returns the watch history, most recent first, with repeated
+// videos collapsed into their latest entry.
+func LoadHistory() ([]HistoryEntry, error) {
+	f, err := os.Open(historyPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var all []HistoryEntry
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var e HistoryEntry
+		if json.Unmarshal(sc.Bytes(), &e) == nil && e.Video.URL != "" {
+			all = append(all, e)
+		}
+	}
+
+	seen := make(map[string]bool)
+	entries := make([]HistoryEntry, 0, len(all))
+	for i := len(all) - 1; i >= 0; i-- {
+		if seen[all[i].Video.URL] {
+			continue
+		}
+		seen[all[i].Video.URL] = true
+		if p, ok := thumbCache.Lookup(all[i].Video.Thumbnail); ok {
+			all[i].Video.ThumbnailPath = p
+		}
+		entries = append(entries, all[i])
+	}
+	return entries, sc.Err()
+}
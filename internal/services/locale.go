@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Locale selects the interface language (hl) and content region (gl) that
+// YouTube is asked for.
+type Locale struct {
+	Language string // e.g. "en", "de", "pt-BR", "es-419"
+	Region   string // ISO 3166 code, e.g. "US", "DE"
+}
+
+// DefaultLocale is what the scraper used before the locale was configurable.
+var DefaultLocale = Locale{Language: "en", Region: "US"}
+
+var (
+	// A language with a script, country or UN M.49 region subtag, e.g.
+	// zh-Hant, pt-BR or es-419.
+	languageRegex = regexp.MustCompile(`^[a-z]{2,3}(-([A-Za-z]{2,4}|[0-9]{3}))?$`)
+	regionRegex   = regexp.MustCompile(`^[A-Z]{2}$`)
+)
+
+var locale = DefaultLocale
+
+// ParseLocale validates a language and region pair.
+func ParseLocale(language, region string) (Locale, error) {
+	l := Locale{Language: strings.TrimSpace(language), Region: strings.ToUpper(strings.TrimSpace(region))}
+	if !languageRegex.MatchString(l.Language) {
+		return l, fmt.Errorf("invalid language %q, expected a code like en, pt-BR or es-419", language)
+	}
+	if !regionRegex.MatchString(l.Region) {
+		return l, fmt.Errorf("invalid region %q, expected a two letter code like US", region)
+	}
+	return l, nil
+}
+
+// SetLocale changes the locale used by all requests.
+func SetLocale(l Locale) {
+	locale = l
+}
+
+// base returns the language without its region subtag.
+func (l Locale) base() string {
+	lang, _, _ := strings.Cut(l.Language, "-")
+	return lang
+}
+
+// query returns the URL parameters selecting l.
+func (l Locale) query() string {
+	return "hl=" + l.Language + "&gl=" + l.Region
+}
+
+// acceptLanguage returns the matching Accept-Language header value.
+func (l Locale) acceptLanguage() string {
+	tag := l.Language
+	if !strings.Contains(tag, "-") {
+		tag += "-" + l.Region
+	}
+	if l.base() == "en" {
+		return tag + ",en;q=0.9"
+	}
+	return fmt.Sprintf("%s,%s;q=0.9,en;q=0.5", tag, l.base())
+}
+
+// newRequest builds a GET request carrying the user agent and locale
+// headers.
+func newRequest(ctx context.Context, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", network.UserAgent)
+	req.Header.Set("Accept-Language", locale.acceptLanguage())
+	return req, nil
+}
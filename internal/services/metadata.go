@@ -0,0 +1,264 @@
+package services
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// YouTube formats durations, view counts and dates according to the hl
+// parameter of the request. The parsers below accept the notations of the
+// languages YouTube offers and fall back to zero values for anything else.
+
+var (
+	durationRegex = regexp.MustCompile(`^(\d+)(?:[:.](\d{1,2}))?(?:[:.](\d{1,2}))?$`)
+	// A number with any grouping or decimal separators, followed by the rest.
+	countRegex = regexp.MustCompile(`(\d[\d.,'\s\x{00a0}\x{202f}]*)(.*)`)
+	// Fewer than three digits after the last separator are a fraction.
+	decimalRegex = regexp.MustCompile(`[.,]\d{1,2}$`)
+	// A word cut short with a period, like "mio." or "t.".
+	abbreviationRegex = regexp.MustCompile(`^\pL+\.`)
+)
+
+// ParseDuration parses lengths like "1:02:33", "12:05" or "0:45".
+func ParseDuration(s string) time.Duration {
+	m := durationRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0
+	}
+	parts := []int{}
+	for _, p := range m[1:] {
+		if p == "" {
+			continue
+		}
+		n, _ := strconv.Atoi(p)
+		parts = append(parts, n)
+	}
+	var d time.Duration
+	for _, n := range parts {
+		d = d*60 + time.Duration(n)
+	}
+	return d * time.Second
+}
+
+// countSuffix is an abbreviation YouTube uses for large counts and its
+// multiplier.
+type countSuffix struct {
+	prefix string
+	mult   float64
+}
+
+// countSuffixes holds the abbreviations of each language, lower case. The
+// same letters mean different things in different languages: "m" is a
+// million in English and a billion in Indonesian, "b" a billion in English
+// and a thousand in Turkish. Indian English counts in lakh and crore.
+var countSuffixes = map[string][]countSuffix{
+	"en": {{"k", 1e3}, {"m", 1e6}, {"b", 1e9}, {"lakh", 1e5}, {"crore", 1e7}},
+	"de": {{"tsd", 1e3}, {"mio", 1e6}, {"mrd", 1e9}},
+	"fr": {{"k", 1e3}, {"m", 1e6}, {"md", 1e9}},
+	"es": {{"mil", 1e3}, {"m", 1e6}},
+	"pt": {{"mil", 1e3}, {"mi", 1e6}, {"bi", 1e9}},
+	"it": {{"mln", 1e6}, {"mrd", 1e9}},
+	"nl": {{"k", 1e3}, {"mln", 1e6}, {"mld", 1e9}},
+	"sv": {{"tn", 1e3}, {"mn", 1e6}, {"md", 1e9}},
+	"da": {{"t", 1e3}, {"mio", 1e6}, {"mia", 1e9}},
+	"nb": {{"k", 1e3}, {"mill", 1e6}, {"mrd", 1e9}},
+	"no": {{"k", 1e3}, {"mill", 1e6}, {"mrd", 1e9}},
+	"fi": {{"t", 1e3}, {"milj", 1e6}, {"mrd", 1e9}},
+	"hu": {{"e", 1e3}, {"m", 1e6}, {"mrd", 1e9}},
+	"pl": {{"tys", 1e3}, {"mln", 1e6}, {"mld", 1e9}},
+	"cs": {{"tis", 1e3}, {"mil", 1e6}, {"mld", 1e9}},
+	"ru": {{"тыс", 1e3}, {"млн", 1e6}, {"млрд", 1e9}},
+	"uk": {{"тис", 1e3}, {"млн", 1e6}, {"млрд", 1e9}},
+	"tr": {{"b", 1e3}, {"mn", 1e6}, {"mr", 1e9}},
+	"id": {{"rb", 1e3}, {"jt", 1e6}, {"m", 1e9}},
+	"ms": {{"k", 1e3}, {"j", 1e6}, {"b", 1e9}},
+	"vi": {{"n", 1e3}, {"tr", 1e6}, {"t", 1e9}},
+	"hi": {{"हज़ार", 1e3}, {"हजार", 1e3}, {"लाख", 1e5}, {"क॰", 1e7}, {"करोड़", 1e7}},
+	"ar": {{"ألف", 1e3}, {"آلاف", 1e3}, {"مليون", 1e6}, {"مليار", 1e9}},
+	"th": {{"พัน", 1e3}, {"หมื่น", 1e4}, {"แสน", 1e5}, {"ล้าน", 1e6}},
+	"ja": {{"万", 1e4}, {"億", 1e8}},
+	"zh": {{"千", 1e3}, {"万", 1e4}, {"萬", 1e4}, {"亿", 1e8}, {"億", 1e8}},
+	"ko": {{"천", 1e3}, {"만", 1e4}, {"억", 1e8}},
+}
+
+// noViews are the words used for videos that have not been watched yet.
+var noViews = []string{"no views", "keine aufrufe", "aucune vue", "sin visualizaciones", "nenhuma visualização", "нет просмотров", "再生なし", "조회수 없음", "belum ditonton", "görüntüleme yok", "chưa có lượt xem", "कोई व्यू नहीं"}
+
+// watchingWords follow the number of people watching a live stream, which
+// YouTube shows in place of the view count.
+var watchingWords = []string{"watching", "zuschauer", "spectateur", "espectador", "assistindo", "spettator", "kijker", "oglądając", "izleyici", "menonton", "đang xem", "смотрят", "глядач", "देख रहे", "視聴中", "正在观看", "正在觀看", "시청 중"}
+
+// ParseViewCount parses counts like "1,234,567 views", "1.2M views",
+// "1,2 Mio. Aufrufe", "12 тыс. просмотров" or "3.4万回視聴". lang is the
+// base language code the page was requested in, only its abbreviations
+// are recognized. Abbreviated counts in other languages are 0, unknown, and
+// so is the number of people watching a live stream, see ParseViewers.
+func ParseViewCount(s, lang string) int64 {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, nv := range noViews {
+		if strings.HasPrefix(s, nv) {
+			return 0
+		}
+	}
+	if isWatching(s) {
+		return 0
+	}
+	return parseCount(s, lang)
+}
+
+// ParseViewers parses the number of people watching a live stream, like
+// "1.2K watching" or "1234 Zuschauer". Anything else is 0.
+func ParseViewers(s, lang string) int64 {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if !isWatching(s) {
+		return 0
+	}
+	return parseCount(s, lang)
+}
+
+func isWatching(s string) bool {
+	for _, w := range watchingWords {
+		if strings.Contains(s, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCount parses the lower case count at the start of s.
+func parseCount(s, lang string) int64 {
+	m := countRegex.FindStringSubmatch(s)
+	if m == nil {
+		return 0
+	}
+	num := strings.TrimRightFunc(m[1], func(r rune) bool { return !unicode.IsDigit(r) })
+	rest := strings.TrimLeftFunc(m[2], unicode.IsSpace)
+
+	if lang == "" {
+		lang = "en"
+	}
+	for _, suf := range countSuffixes[lang] {
+		if !hasToken(rest, suf.prefix) {
+			continue
+		}
+		// With a multiplier the separator is a decimal one
+		f, err := strconv.ParseFloat(strings.Map(decimalRune, num), 64)
+		if err != nil {
+			return 0
+		}
+		return int64(f*suf.mult + 0.5)
+	}
+	// A fraction or an abbreviation like "milj." means a multiplier this
+	// language has no entry for, better unknown than off by a million
+	if decimalRegex.MatchString(num) || abbreviationRegex.MatchString(rest) {
+		return 0
+	}
+
+	// No multiplier: every separator is a grouping separator
+	n, err := strconv.ParseInt(strings.Map(digitRune, num), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// hasToken reports whether s starts with the word prefix, not just with
+// its letters: "m" is in "1,2 M megtekintés" but not in "megtekintés".
+// Scripts without spaces, like "3.4万回視聴", only need the prefix.
+func hasToken(s, prefix string) bool {
+	if !strings.HasPrefix(s, prefix) {
+		return false
+	}
+	r, _ := utf8.DecodeRuneInString(s[len(prefix):])
+	return isCJK(prefix) || r == utf8.RuneError || !unicode.IsLetter(r) && !unicode.IsMark(r)
+}
+
+func digitRune(r rune) rune {
+	if unicode.IsDigit(r) {
+		return r
+	}
+	return -1
+}
+
+// decimalRune keeps digits and turns either separator into a decimal point.
+func decimalRune(r rune) rune {
+	switch {
+	case unicode.IsDigit(r):
+		return r
+	case r == '.' || r == ',':
+		return '.'
+	}
+	return -1
+}
+
+// timeUnits maps words for units of time to their length. Latin and
+// Cyrillic entries match the start of a word, the CJK and Hangul ones the
+// start of the text following the number.
+var timeUnits = []struct {
+	unit  time.Duration
+	stems []string
+}{
+	{time.Second, []string{"second", "sec", "sekund", "seconde", "segundo", "secondi", "seconden", "saniye", "detik", "giây", "секунд", "सेकंड", "秒", "초"}},
+	{time.Minute, []string{"minut", "minute", "minuto", "minuti", "minuten", "dakika", "menit", "phút", "минут", "मिनट", "分", "분"}},
+	{time.Hour, []string{"hour", "hr", "stunde", "heure", "hora", "ora", "ore", "uur", "saat", "godzin", "jam", "giờ", "timm", "time", "час", "घंट", "時間", "小时", "小時", "시간"}},
+	{7 * 24 * time.Hour, []string{"week", "woche", "semaine", "semana", "settiman", "hafta", "tydzie", "tygodni", "minggu", "tuần", "veck", "uke", "uge", "недел", "सप्ताह", "हफ़्त", "हफ्त", "週", "周", "주"}},
+	{30 * 24 * time.Hour, []string{"month", "monat", "mois", "mes", "mês", "mese", "maand", "miesi", "ay", "bulan", "tháng", "månad", "måned", "месяц", "महीन", "か月", "ヶ月", "カ月", "个月", "個月", "개월"}},
+	{365 * 24 * time.Hour, []string{"year", "jahr", "an", "año", "ano", "anno", "anni", "jaar", "yıl", "rok", "lat", "tahun", "năm", "år", "год", "лет", "साल", "वर्ष", "年", "년"}},
+	{24 * time.Hour, []string{"day", "tag", "jour", "día", "dia", "giorn", "dag", "gün", "dzień", "dni", "hari", "ngày", "дн", "день", "दिन", "日", "天", "일"}},
+}
+
+var firstNumberRegex = regexp.MustCompile(`\d+`)
+
+// ParsePublished turns relative dates like "3 weeks ago", "Streamed 2 days
+// ago", "vor 3 Wochen" or "3週間前" into an approximate point in time
+// relative to now. Absolute dates like "Jan 2, 2006" are parsed as well.
+func ParsePublished(s string, now time.Time) time.Time {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return time.Time{}
+	}
+	for _, layout := range []string{"jan 2, 2006", "2 jan 2006", "2006-01-02", "2006/01/02", "02.01.2006"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+
+	n := 1
+	after := s
+	if loc := firstNumberRegex.FindStringIndex(s); loc != nil {
+		n, _ = strconv.Atoi(s[loc[0]:loc[1]])
+		after = strings.TrimSpace(s[loc[1]:])
+	}
+	// Marks are part of words in Devanagari and other Indic scripts
+	words := strings.FieldsFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsMark(r) })
+
+	for _, tu := range timeUnits {
+		for _, stem := range tu.stems {
+			if isCJK(stem) {
+				if strings.HasPrefix(after, stem) {
+					return now.Add(-time.Duration(n) * tu.unit)
+				}
+				continue
+			}
+			for _, w := range words {
+				if strings.HasPrefix(w, stem) && (len(stem) > 2 || len(w) <= len(stem)+2) {
+					return now.Add(-time.Duration(n) * tu.unit)
+				}
+			}
+		}
+	}
+	return time.Time{}
+}
+
+func isCJK(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hangul, r) || unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hiragana, r) {
+			return true
+		}
+	}
+	return false
+}
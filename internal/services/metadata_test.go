@@ -0,0 +1,135 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseViewCount(t *testing.T) {
+	tests := []struct {
+		lang, in string
+		want     int64
+	}{
+		{"en", "1,234,567 views", 1234567},
+		{"en", "1.2M views", 1200000},
+		{"en", "12K views", 12000},
+		{"en", "3.1B views", 3100000000},
+		{"en", "1 view", 1},
+		{"en", "No views", 0},
+		{"en", "10 lakh views", 1000000},
+		{"en", "1.5 crore views", 15000000},
+		{"en", "123 watching", 0},
+		{"en", "1.2K watching", 0},
+		{"", "987 views", 987},
+		{"de", "1,2 Mio. Aufrufe", 1200000},
+		{"de", "1.234.567 Aufrufe", 1234567},
+		{"de", "12 Tsd. Aufrufe", 12000},
+		{"de", "Keine Aufrufe", 0},
+		{"de", "1.234 Zuschauer", 0},
+		{"fr", "1 234 567 vues", 1234567},
+		{"fr", "1\u202f234\u202f567 vues", 1234567},
+		{"fr", "1,2 M de vues", 1200000},
+		{"fr", "12 k vues", 12000},
+		{"es", "1,2 M de visualizaciones", 1200000},
+		{"es", "12 mil visualizaciones", 12000},
+		{"pt", "1,2 mi de visualizações", 1200000},
+		{"ru", "12 тыс. просмотров", 12000},
+		{"ru", "1,2 млн просмотров", 1200000},
+		{"ru", "1 234 смотрят", 0},
+		{"hi", "10 लाख व्यू", 1000000},
+		{"ja", "3.4万回視聴", 34000},
+		{"ja", "1,234回視聴", 1234},
+		{"ja", "1234 人が視聴中", 0},
+		{"zh", "1.2万次观看", 12000},
+		{"ko", "조회수 1.2만회", 12000},
+		{"tr", "12 B görüntüleme", 12000},
+		{"id", "1,2 jt x ditonton", 1200000},
+		// Multipliers of other languages are unknown, not off by a million
+		{"en", "1,2 Mio. Aufrufe", 0},
+		{"de", "1.2M views", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		if got := ParseViewCount(tt.in, tt.lang); got != tt.want {
+			t.Errorf("ParseViewCount(%q, %q) = %d, want %d", tt.in, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestParseViewers(t *testing.T) {
+	tests := []struct {
+		lang, in string
+		want     int64
+	}{
+		{"en", "123 watching", 123},
+		{"en", "1.2K watching", 1200},
+		{"de", "1.234 Zuschauer", 1234},
+		{"fr", "1 234 spectateurs", 1234},
+		{"ja", "1234 人が視聴中", 1234},
+		{"zh", "1.2万 人正在观看", 12000},
+		{"en", "1,234 views", 0},
+		{"ru", "1 234 просмотров", 0},
+		{"zh", "1.2万次观看", 0},
+	}
+	for _, tt := range tests {
+		if got := ParseViewers(tt.in, tt.lang); got != tt.want {
+			t.Errorf("ParseViewers(%q, %q) = %d, want %d", tt.in, tt.lang, got, tt.want)
+		}
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"1:02:33", time.Hour + 2*time.Minute + 33*time.Second},
+		{"12:05", 12*time.Minute + 5*time.Second},
+		{"0:45", 45 * time.Second},
+		{" 3:07 ", 3*time.Minute + 7*time.Second},
+		{"1.02.33", time.Hour + 2*time.Minute + 33*time.Second},
+		{"", 0},
+		{"LIVE", 0},
+		{"1:2:3:4", 0},
+	}
+	for _, tt := range tests {
+		if got := ParseDuration(tt.in); got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParsePublished(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"3 weeks ago", now.Add(-21 * day)},
+		{"Streamed 2 days ago", now.Add(-2 * day)},
+		{"1 year ago", now.Add(-365 * day)},
+		{"5 hours ago", now.Add(-5 * time.Hour)},
+		{"vor 3 Wochen", now.Add(-21 * day)},
+		{"vor 2 Monaten", now.Add(-60 * day)},
+		{"il y a 2 jours", now.Add(-2 * day)},
+		{"il y a 3 ans", now.Add(-3 * 365 * day)},
+		{"hace 1 año", now.Add(-365 * day)},
+		{"há 4 meses", now.Add(-120 * day)},
+		{"2 недели назад", now.Add(-14 * day)},
+		{"10 minut temu", now.Add(-10 * time.Minute)},
+		{"3週間前", now.Add(-21 * day)},
+		{"2年前", now.Add(-2 * 365 * day)},
+		{"5天前", now.Add(-5 * day)},
+		{"3일 전", now.Add(-3 * day)},
+		{"Jan 2, 2006", time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"2006-01-02", time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"", time.Time{}},
+		{"Premieres soon", time.Time{}},
+	}
+	for _, tt := range tests {
+		if got := ParsePublished(tt.in, now); !got.Equal(tt.want) {
+			t.Errorf("ParsePublished(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
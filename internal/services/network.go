@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// NetworkConfig controls how every request of this package, and of the
+// yt-dlp and mpv processes started with YtDlpArgs and MpvArgs, reaches
+// YouTube.
+type NetworkConfig struct {
+	Proxy                string        // http://, https://, socks5:// or socks5h:// URL, empty uses $HTTPS_PROXY
+	UserAgent            string        // sent with scraping and thumbnail requests
+	Timeout              time.Duration // per search request
+	ThumbnailTimeout     time.Duration // per thumbnail request
+	Retries              int           // extra attempts after a failed request
+	RetryDelay           time.Duration // wait before the first retry, doubled for every further one
+	MaxRetryDelay        time.Duration // upper bound of the wait between retries
+	ThumbnailConcurrency int           // thumbnails downloaded at once
+	CABundle             string        // PEM file trusted in addition to the system roots
+	IPVersion            int           // 4 or 6 to force a protocol, 0 for either
+}
+
+// DefaultUserAgent is a current desktop browser, YouTube serves the same
+// page layout to it that the parser expects.
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/130.0.0.0 Safari/537.36"
+
+// DefaultNetworkConfig is used until SetNetwork is called.
+var DefaultNetworkConfig = NetworkConfig{
+	UserAgent:            DefaultUserAgent,
+	Timeout:              10 * time.Second,
+	ThumbnailTimeout:     4 * time.Second,
+	Retries:              2,
+	RetryDelay:           500 * time.Millisecond,
+	MaxRetryDelay:        8 * time.Second,
+	ThumbnailConcurrency: 8,
+}
+
+var (
+	network    = DefaultNetworkConfig
+	httpClient = newHTTPClient(newTransport(http.ProxyFromEnvironment, nil, 0))
+)
+
+// SetNetwork validates c and routes all further requests accordingly.
+func SetNetwork(c NetworkConfig) error {
+	c, proxy, roots, err := parseNetwork(c)
+	if err != nil {
+		return err
+	}
+
+	network = c
+	if cap(thumbSlots) != c.ThumbnailConcurrency {
+		thumbSlots = make(chan struct{}, c.ThumbnailConcurrency)
+	}
+	CleanupHTTPConnections()
+	httpClient = newHTTPClient(newTransport(proxy, roots, c.IPVersion))
+	return nil
+}
+
+// CheckNetwork reports what SetNetwork would reject in c without applying it.
+func CheckNetwork(c NetworkConfig) error {
+	_, _, _, err := parseNetwork(c)
+	return err
+}
+
+// parseNetwork validates c and loads the proxy and certificates it names.
+func parseNetwork(c NetworkConfig) (NetworkConfig, func(*http.Request) (*url.URL, error), *x509.CertPool, error) {
+	if c.UserAgent == "" {
+		c.UserAgent = DefaultUserAgent
+	}
+	if c.Retries < 0 {
+		return c, nil, nil, fmt.Errorf("network: retries must not be negative")
+	}
+	if c.ThumbnailConcurrency < 1 {
+		return c, nil, nil, fmt.Errorf("network: thumbnail_concurrency must be at least 1")
+	}
+	if c.IPVersion != 0 && c.IPVersion != 4 && c.IPVersion != 6 {
+		return c, nil, nil, fmt.Errorf("network: invalid ip_version %d, expected 4 or 6", c.IPVersion)
+	}
+
+	proxy := http.ProxyFromEnvironment
+	if c.Proxy != "" {
+		u, err := url.Parse(c.Proxy)
+		if err != nil || u.Host == "" {
+			return c, nil, nil, fmt.Errorf("network: invalid proxy %q", c.Proxy)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return c, nil, nil, fmt.Errorf("network: unsupported proxy scheme %q, use http, https, socks5 or socks5h", u.Scheme)
+		}
+		proxy = http.ProxyURL(u)
+	}
+
+	var roots *x509.CertPool
+	if c.CABundle != "" {
+		pem, err := os.ReadFile(c.CABundle)
+		if err != nil {
+			return c, nil, nil, fmt.Errorf("network: ca_bundle: %w", err)
+		}
+		if roots, err = x509.SystemCertPool(); err != nil {
+			roots = x509.NewCertPool()
+		}
+		if !roots.AppendCertsFromPEM(pem) {
+			return c, nil, nil, fmt.Errorf("network: ca_bundle %s contains no certificates", c.CABundle)
+		}
+	}
+	return c, proxy, roots, nil
+}
+
+// Network returns the active configuration.
+func Network() NetworkConfig {
+	return network
+}
+
+// newHTTPClient leaves timeouts to the requests, searches and thumbnails
+// use different ones.
+func newHTTPClient(t *http.Transport) *http.Client {
+	return &http.Client{Transport: t, Jar: cookies}
+}
+
+func newTransport(proxy func(*http.Request) (*url.URL, error), roots *x509.CertPool, ipVersion int) *http.Transport {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, KeepAlive: 30 * time.Second}
+	dial := dialer.DialContext
+	if ipVersion != 0 {
+		// "tcp" would fall back to the other family
+		forced := fmt.Sprintf("tcp%d", ipVersion)
+		dial = func(ctx context.Context, _, addr string) (net.Conn, error) {
+			return dialer.DialContext(ctx, forced, addr)
+		}
+	}
+	t := &http.Transport{
+		Proxy:               proxy,
+		DialContext:         dial,
+		ForceAttemptHTTP2:   true,
+		MaxIdleConns:        100,
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	if roots != nil {
+		t.TLSClientConfig = &tls.Config{RootCAs: roots}
+	}
+	return t
+}
+
+// get performs a GET request with the locale and user agent headers,
+// retrying transient failures as decided by retryWait. Every attempt is
+// bounded by timeout, the whole request by ctx.
+func get(ctx context.Context, url string, timeout time.Duration, header http.Header) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		body, err := getOnce(ctx, url, timeout, header)
+		if err == nil {
+			return body, nil
+		}
+		wait, ok := retryWait(err, attempt)
+		if !ok || ctx.Err() != nil {
+			return nil, err
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// maxBodySize bounds what is read from a single response.
+const maxBodySize = 16 << 20
+
+func getOnce(ctx context.Context, url string, timeout time.Duration, header http.Header) ([]byte, error) {
+	attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := newRequest(attemptCtx, url)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	youtube := isYouTubeHost(req.URL.Hostname())
+	if youtube {
+		if err := cookies.load(ctx); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, networkError(ctx, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, networkError(ctx, err)
+	}
+	if youtube {
+		dumpResponse(resp, body)
+	}
+	if err := classifyResponse(resp); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// networkError wraps a transport error in ErrNetwork unless it is the
+// caller canceling, which is reported as is and never retried.
+func networkError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return fmt.Errorf("%w: %w", ErrNetwork, err)
+}
+
+// proxyURL is the proxy handed to child processes, the configured one or
+// the one from the environment.
+func proxyURL() string {
+	if network.Proxy != "" {
+		return network.Proxy
+	}
+	for _, env := range []string{"HTTPS_PROXY", "https_proxy"} {
+		if v := os.Getenv(env); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// YtDlpArgs returns the yt-dlp options that send its traffic the same way,
+// and with the same cookies, as the requests of this package.
+func YtDlpArgs() []string {
+	var args []string
+	switch c := cookies.config; {
+	case c.File != "":
+		args = append(args, "--cookies", c.File)
+	case c.Browser != "":
+		args = append(args, "--cookies-from-browser", c.Browser)
+	}
+	if p := proxyURL(); p != "" {
+		args = append(args, "--proxy", p)
+	}
+	switch network.IPVersion {
+	case 4:
+		args = append(args, "--force-ipv4")
+	case 6:
+		args = append(args, "--force-ipv6")
+	}
+	return args
+}
+
+// MpvArgs returns the mpv options doing the same as YtDlpArgs for its
+// youtube-dl hook and, for HTTP proxies, for the stream itself.
+func MpvArgs() []string {
+	var args []string
+	switch c := cookies.config; {
+	case c.File != "":
+		args = append(args, "--ytdl-raw-options-append=cookies="+c.File, "--cookies", "--cookies-file="+c.File)
+	case c.Browser != "":
+		args = append(args, "--ytdl-raw-options-append=cookies-from-browser="+c.Browser)
+	}
+	p := proxyURL()
+	if p != "" {
+		args = append(args, "--ytdl-raw-options-append=proxy="+p)
+		if strings.HasPrefix(p, "http://") {
+			// mpv cannot stream through HTTPS or SOCKS proxies
+			args = append(args, "--http-proxy="+p)
+		}
+	}
+	switch network.IPVersion {
+	case 4:
+		args = append(args, "--ytdl-raw-options-append=force-ipv4=")
+	case 6:
+		args = append(args, "--ytdl-raw-options-append=force-ipv6=")
+	}
+	return args
+}
@@ -0,0 +1,39 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// offline makes every function in this package work from local caches
+// only, without touching the network.
+var offline bool
+
+// SetOffline switches the package in or out of offline mode.
+func SetOffline(v bool) {
+	offline = v
+}
+
+// Offline reports whether offline mode is active.
+func Offline() bool {
+	return offline
+}
+
+// CheckConnectivity reports whether YouTube can be reached within timeout.
+// The check takes the configured route, so a working proxy counts as
+// online even when a direct connection is blocked.
+func CheckConnectivity(ctx context.Context, timeout time.Duration) bool {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "HEAD", "https://www.youtube.com/generate_204", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return true
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gophertube/internal/types"
+)
+
+func queuePath() string {
+	return filepath.Join(DefaultDataDir(), "queue.json")
+}
+
+// SaveQueue stores the queued videos so they survive a restart. An empty
+// queue removes the file.
+func SaveQueue(videos []types.Video) error {
+	if len(videos) == 0 {
+		if err := os.Remove(queuePath()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	saved := make([]types.Video, len(videos))
+	for i, v := range videos {
+		v.ThumbnailPath = "" // depends on the cache, resolved again when loaded
+		saved[i] = v
+	}
+	data, err := json.Marshal(saved)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(DefaultDataDir(), 0o700); err != nil {
+		return err
+	}
+	// Written aside and renamed so a crash never leaves half a queue
+	tmp := queuePath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, queuePath())
+}
+
+// LoadQueue returns the videos saved by SaveQueue.
+func LoadQueue() ([]types.Video, error) {
+	data, err := os.ReadFile(queuePath())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var videos []types.Video
+	if err := json.Unmarshal(data, &videos); err != nil {
+		return nil, err
+	}
+	for i := range videos {
+		if p, ok := thumbCache.Lookup(videos[i].Thumbnail); ok {
+			videos[i].ThumbnailPath = p
+		}
+	}
+	return videos, nil
+}
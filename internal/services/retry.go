@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// maxRetryAfter is the longest Retry-After that is waited out, anything
+// longer fails right away so the user is not left staring at a spinner.
+const maxRetryAfter = 30 * time.Second
+
+// retryWait decides whether the request that failed with err on the given
+// attempt (counted from 0) is tried again, and after how long.
+func retryWait(err error, attempt int) (time.Duration, bool) {
+	if attempt >= network.Retries || errors.Is(err, ErrCaptcha) {
+		return 0, false
+	}
+	var se *StatusError
+	switch {
+	case errors.As(err, &se):
+		if se.Code != http.StatusTooManyRequests && se.Code < 500 {
+			return 0, false
+		}
+		if se.RetryAfter > maxRetryAfter {
+			return 0, false
+		}
+		if se.RetryAfter > 0 {
+			return se.RetryAfter, true
+		}
+	case !errors.Is(err, ErrNetwork):
+		return 0, false
+	}
+	return backoff(attempt, network.RetryDelay, network.MaxRetryDelay), true
+}
+
+// backoff doubles base with every attempt up to max and randomizes the
+// upper half, so that parallel requests do not retry in lockstep.
+func backoff(attempt int, base, max time.Duration) time.Duration {
+	d := base << attempt
+	if d <= 0 || d > max {
+		d = max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+// thumbSlots bounds the number of thumbnails downloaded at once across all
+// searches.
+var thumbSlots = make(chan struct{}, DefaultNetworkConfig.ThumbnailConcurrency)
+
+// withThumbSlot runs fn once a download slot is free, or not at all when
+// ctx is canceled first.
+func withThumbSlot(ctx context.Context, fn func()) {
+	slots := thumbSlots
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return
+	}
+	defer func() { <-slots }()
+	fn()
+}
@@ -0,0 +1,174 @@
+package services
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gophertube/internal/types"
+)
+
+// DefaultSearchCacheTTL is how long parsed results are served without
+// asking YouTube again.
+const DefaultSearchCacheTTL = time.Hour
+
+// searchCacheKeep is how long results are kept past their TTL, to be
+// served when YouTube cannot be reached. Older ones are deleted.
+const searchCacheKeep = 30 * 24 * time.Hour
+
+// SearchCache stores parsed search results on disk, keyed by query and
+// search filters.
+type SearchCache struct {
+	Dir string
+	TTL time.Duration
+}
+
+// searchEntry is the on-disk format of one cached search.
+type searchEntry struct {
+	Query   string
+	Filters string
+	Limit   int // limit the results were fetched with
+	Fetched time.Time
+	Videos  []types.Video
+}
+
+// searchCache is nil when caching is disabled.
+var searchCache = NewSearchCache(DefaultSearchCacheDir(), DefaultSearchCacheTTL)
+
+// NewSearchCache returns a cache rooted at dir.
+func NewSearchCache(dir string, ttl time.Duration) *SearchCache {
+	return &SearchCache{Dir: dir, TTL: ttl}
+}
+
+// SetSearchCache replaces the cache used by SearchYouTube, nil disables it.
+func SetSearchCache(c *SearchCache) {
+	searchCache = c
+}
+
+// Searches returns the cache used by SearchYouTube, possibly nil.
+func Searches() *SearchCache {
+	return searchCache
+}
+
+// DefaultSearchCacheDir is $XDG_CACHE_HOME/gophertube/search.
+func DefaultSearchCacheDir() string {
+	return filepath.Join(DefaultCacheDir(), "search")
+}
+
+func (c *SearchCache) path(query, filters string) string {
+	return filepath.Join(c.Dir, fmt.Sprintf("%x.json", sha1.Sum([]byte(filters+"\x00"+query))))
+}
+
+// Load returns at most limit cached results for query. Entries older than
+// the TTL are only returned when stale is set. A hit requires the entry to
+// hold limit results, or everything YouTube had when it was fetched.
+func (c *SearchCache) Load(query, filters string, limit int, stale bool) ([]types.Video, bool) {
+	data, err := os.ReadFile(c.path(query, filters))
+	if err != nil {
+		return nil, false
+	}
+	var e searchEntry
+	if err := json.Unmarshal(data, &e); err != nil || e.Query != query || e.Filters != filters {
+		return nil, false
+	}
+	if !stale && time.Since(e.Fetched) > c.TTL {
+		return nil, false
+	}
+	if len(e.Videos) < limit && e.Limit < limit && !stale {
+		return nil, false
+	}
+	if len(e.Videos) > limit {
+		e.Videos = e.Videos[:limit]
+	}
+	return e.Videos, len(e.Videos) > 0
+}
+
+// Save records videos as the results for query fetched with limit, and
+// deletes the entries too old to be served even offline.
+func (c *SearchCache) Save(query, filters string, limit int, videos []types.Video) error {
+	data, err := json.Marshal(searchEntry{
+		Query:   query,
+		Filters: filters,
+		Limit:   limit,
+		Fetched: time.Now(),
+		Videos:  videos,
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return err
+	}
+	p := c.path(query, filters)
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return err
+	}
+	return c.prune()
+}
+
+// prune deletes entries saved longer ago than the TTL and searchCacheKeep,
+// and temp files a crashed Save left behind. An entry's modification time
+// is when it was fetched.
+func (c *SearchCache) prune() error {
+	entries, err := dirEntries(c.Dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if time.Since(e.mod) > max(c.TTL, searchCacheKeep) || strings.HasSuffix(e.path, ".tmp") && time.Since(e.mod) > time.Minute {
+			os.Remove(e.path)
+		}
+	}
+	return nil
+}
+
+// CachedSearch describes one entry of the search cache.
+type CachedSearch struct {
+	Query   string
+	Fetched time.Time
+	Count   int
+}
+
+// List returns the cached searches, most recent first.
+func (c *SearchCache) List() ([]CachedSearch, error) {
+	entries, err := dirEntries(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var list []CachedSearch
+	for _, de := range entries {
+		if !strings.HasSuffix(de.path, ".json") {
+			continue
+		}
+		data, err := os.ReadFile(de.path)
+		if err != nil {
+			continue
+		}
+		var e searchEntry
+		if json.Unmarshal(data, &e) != nil || e.Filters != searchFilters() || len(e.Videos) == 0 {
+			continue
+		}
+		list = append(list, CachedSearch{Query: e.Query, Fetched: e.Fetched, Count: len(e.Videos)})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Fetched.After(list[j].Fetched) })
+	return list, nil
+}
+
+// Stats reports the number of cached searches and their size.
+func (c *SearchCache) Stats() (CacheStats, error) {
+	return dirStats(c.Dir)
+}
+
+// Clear removes every cached search.
+func (c *SearchCache) Clear() error {
+	return os.RemoveAll(c.Dir)
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultThumbCacheSize is the thumbnail cache limit in bytes.
+const DefaultThumbCacheSize = 100 << 20
+
+// thumbTempPrefix starts the names of thumbnails still being written.
+// Trim leaves them alone unless they are older than staleTempAge, left
+// behind by a crash.
+const (
+	thumbTempPrefix = ".thumb-"
+	staleTempAge    = time.Minute
+)
+
+// ThumbCache keeps downloaded thumbnails in a private directory and bounds
+// its size by evicting the least recently used files.
+type ThumbCache struct {
+	Dir      string
+	MaxBytes int64 // 0 disables eviction
+
+	mu sync.Mutex
+}
+
+// CacheStats summarizes the content of a cache directory.
+type CacheStats struct {
+	Files int
+	Bytes int64
+}
+
+var thumbCache = NewThumbCache(DefaultThumbDir(), DefaultThumbCacheSize)
+
+// NewThumbCache returns a cache rooted at dir.
+func NewThumbCache(dir string, maxBytes int64) *ThumbCache {
+	return &ThumbCache{Dir: dir, MaxBytes: maxBytes}
+}
+
+// SetThumbCache replaces the cache used by SearchYouTube.
+func SetThumbCache(c *ThumbCache) {
+	thumbCache = c
+}
+
+// Thumbs returns the cache used by SearchYouTube.
+func Thumbs() *ThumbCache {
+	return thumbCache
+}
+
+// DefaultThumbDir is $XDG_CACHE_HOME/gophertube/thumbs.
+func DefaultThumbDir() string {
+	return filepath.Join(DefaultCacheDir(), "thumbs")
+}
+
+// Path returns where the thumbnail for url is stored.
+func (c *ThumbCache) Path(url string) string {
+	return filepath.Join(c.Dir, fmt.Sprintf("%x.jpg", md5.Sum([]byte(url))))
+}
+
+// Lookup returns the cached file for url if it exists and holds an image.
+// A hit refreshes the modification time, which is what eviction sorts by.
+func (c *ThumbCache) Lookup(url string) (string, bool) {
+	p := c.Path(url)
+	f, err := os.Open(p)
+	if err != nil {
+		return "", false
+	}
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	f.Close()
+	if !isImage(head[:n]) {
+		os.Remove(p)
+		return "", false
+	}
+	now := time.Now()
+	os.Chtimes(p, now, now)
+	return p, true
+}
+
+// Store writes data as the thumbnail for url after checking it is an image.
+func (c *ThumbCache) Store(url string, data []byte) (string, error) {
+	if !isImage(data) {
+		return "", fmt.Errorf("thumbnail for %s is not an image", url)
+	}
+	if err := os.MkdirAll(c.Dir, 0o700); err != nil {
+		return "", err
+	}
+	p := c.Path(url)
+	tmp, err := os.CreateTemp(c.Dir, thumbTempPrefix+"*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), p); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return p, nil
+}
+
+type cacheEntry struct {
+	path string
+	size int64
+	mod  time.Time
+}
+
+func dirEntries(dir string) ([]cacheEntry, error) {
+	des, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]cacheEntry, 0, len(des))
+	for _, de := range des {
+		if de.IsDir() {
+			continue
+		}
+		info, err := de.Info()
+		if err != nil {
+			continue
+		}
+		entries = append(entries, cacheEntry{filepath.Join(dir, de.Name()), info.Size(), info.ModTime()})
+	}
+	return entries, nil
+}
+
+func dirStats(dir string) (CacheStats, error) {
+	entries, err := dirEntries(dir)
+	var st CacheStats
+	for _, e := range entries {
+		st.Files++
+		st.Bytes += e.size
+	}
+	return st, err
+}
+
+// Trim evicts the least recently used thumbnails until the cache fits in
+// MaxBytes.
+func (c *ThumbCache) Trim() error {
+	if c.MaxBytes <= 0 {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := dirEntries(c.Dir)
+	if err != nil {
+		return err
+	}
+	// A download renames its temp file into place once written
+	entries = slices.DeleteFunc(entries, func(e cacheEntry) bool {
+		return strings.HasPrefix(filepath.Base(e.path), thumbTempPrefix) && time.Since(e.mod) < staleTempAge
+	})
+	var total int64
+	for _, e := range entries {
+		total += e.size
+	}
+	if total <= c.MaxBytes {
+		return nil
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].mod.Before(entries[j].mod) })
+	for _, e := range entries {
+		if total <= c.MaxBytes {
+			break
+		}
+		if err := os.Remove(e.path); err == nil {
+			total -= e.size
+		}
+	}
+	return nil
+}
+
+// Stats reports the number of files and bytes in the cache.
+func (c *ThumbCache) Stats() (CacheStats, error) {
+	return dirStats(c.Dir)
+}
+
+// Clear removes every cached thumbnail.
+func (c *ThumbCache) Clear() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return os.RemoveAll(c.Dir)
+}
+
+// isImage sniffs data for one of the formats YouTube serves thumbnails in.
+func isImage(data []byte) bool {
+	return len(data) > 0 && strings.HasPrefix(http.DetectContentType(data), "image/")
+}
@@ -1,16 +1,11 @@
 package services
 
 import (
-	"bytes"
-	"crypto/md5"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"net/http"
-	"os"
-	"path/filepath"
 	"regexp"
 	"strings"
 	"sync"
@@ -22,28 +17,16 @@ import (
 // Pre-compiled regex for better performance
 var ytInitialDataRegex = regexp.MustCompile(`(?s)var ytInitialData = (\{.*?\});`)
 
-// Optimized HTTP client with better connection pooling
-var httpClient = &http.Client{
-	Timeout: 10 * time.Second,
-	Transport: &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		IdleConnTimeout:     90 * time.Second,
-		DisableKeepAlives:   false,
-		DisableCompression:  false,
-	},
-}
-
 // Video extraction with early termination
 func extractVideosFromJSON(data []byte, limit int) ([]types.Video, error) {
 	m := ytInitialDataRegex.FindSubmatch(data)
 	if len(m) < 2 {
-		return nil, errors.New("ytInitialData not found")
+		return nil, classifyPage(data)
 	}
 
 	var root map[string]interface{}
 	if err := json.Unmarshal(m[1], &root); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrLayoutChanged, err)
 	}
 
 	videos := make([]types.Video, 0, limit)
@@ -118,31 +101,144 @@ func extractVideosFromJSON(data []byte, limit int) ([]types.Video, error) {
 	extractVideos(root)
 
 	if len(videos) == 0 {
-		return nil, errors.New("no videos found")
+		// An empty search still has the page structure and says so
+		if safeJQString(root, "estimatedResults") == "0" || containsKey(root, "backgroundPromoRenderer") {
+			return nil, ErrNoResults
+		}
+		return nil, fmt.Errorf("%w: no video renderers found", ErrLayoutChanged)
 	}
 
 	return videos, nil
 }
 
-func SearchYouTube(query string, limit int, progress func(current, total int)) ([]types.Video, error) {
+// containsKey reports whether key occurs anywhere in the decoded JSON node.
+func containsKey(node interface{}, key string) bool {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if _, ok := n[key]; ok {
+			return true
+		}
+		for _, v := range n {
+			if containsKey(v, key) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, v := range n {
+			if containsKey(v, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// searchFilter is the sp parameter restricting results to videos.
+const searchFilter = "EgIQAQ%253D%253D"
+
+// searchFilters identifies everything besides the query that changes the
+// results, used to key the search cache.
+func searchFilters() string {
+	return "sp=" + searchFilter + "&" + locale.query()
+}
+
+// SearchYouTube returns up to limit videos for query as soon as the results
+// are parsed. ThumbnailPath is only set for thumbnails that are already
+// cached, the others are downloaded in the background until ctx is
+// canceled; use FetchThumbnail to wait for a specific one. Results come from
+// the search cache while they are fresh, and a stale copy is used when
+// YouTube cannot be reached.
+func SearchYouTube(ctx context.Context, query string, limit int, progress func(current, total int)) ([]types.Video, error) {
 	if progress != nil {
-		progress(0, 2+limit)
+		progress(0, 2)
 	}
 
-	// Single optimized request with best parameters
-	url := "https://www.youtube.com/results?search_query=" + urlQueryEscape(query) + "&sp=EgIQAQ%253D%253D&hl=en&gl=US"
-	resp, err := httpClient.Get(url)
+	var videos []types.Video
+	cached := false
+	if searchCache != nil {
+		videos, cached = searchCache.Load(query, searchFilters(), limit, offline)
+	}
+	if !cached && offline {
+		return nil, ErrOffline
+	}
+	if !cached {
+		var err error
+		videos, err = fetchSearch(ctx, query, limit, progress)
+		if err != nil {
+			if searchCache == nil || errors.Is(err, ErrNoResults) || ctx.Err() != nil {
+				return nil, err
+			}
+			stale, ok := searchCache.Load(query, searchFilters(), limit, true)
+			if !ok {
+				return nil, err
+			}
+			videos = stale
+		} else if searchCache != nil {
+			searchCache.Save(query, searchFilters(), limit, videos)
+		}
+	}
+
+	if progress != nil {
+		progress(2, 2)
+	}
+
+	withThumbnails(ctx, videos)
+	return videos, ctx.Err()
+}
+
+// withThumbnails sets ThumbnailPath for the thumbnails already cached and
+// downloads the others in the background until ctx is canceled.
+func withThumbnails(ctx context.Context, videos []types.Video) {
+	for i := range videos {
+		videos[i].ThumbnailPath, _ = thumbCache.Lookup(videos[i].Thumbnail)
+	}
+	if !offline {
+		go prefetchThumbnails(ctx, append([]types.Video(nil), videos...))
+	}
+}
+
+// ChannelVideos returns up to limit of the latest uploads of the channel
+// that published v. The channel page leaves out who uploaded its videos,
+// so they are all attributed to v's channel.
+func ChannelVideos(ctx context.Context, v types.Video, limit int) ([]types.Video, error) {
+	if offline {
+		return nil, ErrOffline
+	}
+	if v.ChannelID == "" {
+		return nil, fmt.Errorf("no channel known for %q", v.Title)
+	}
+	url := "https://www.youtube.com/channel/" + v.ChannelID + "/videos?" + locale.query()
+	body, err := get(ctx, url, network.Timeout, nil)
+	if err != nil {
+		return nil, err
+	}
+	videos, err := extractVideosFromJSON(body, limit)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+	for i := range videos {
+		if videos[i].Author == "" {
+			videos[i].Author = v.Author
+		}
+		if videos[i].ChannelID == "" {
+			videos[i].ChannelID = v.ChannelID
+		}
+	}
+	withThumbnails(ctx, videos)
+	return videos, ctx.Err()
+}
+
+// fetchSearch scrapes the results page for query.
+func fetchSearch(ctx context.Context, query string, limit int, progress func(current, total int)) ([]types.Video, error) {
+	// Single optimized request with best parameters
+	url := "https://www.youtube.com/results?search_query=" + urlQueryEscape(query) + "&sp=" + searchFilter + "&" + locale.query()
+	body, err := get(ctx, url, network.Timeout, nil)
 	if err != nil {
 		return nil, err
 	}
 
 	if progress != nil {
-		progress(1, 2+limit)
+		progress(1, 2)
 	}
 
 	// Extract videos with early termination
@@ -151,31 +247,23 @@ func SearchYouTube(query string, limit int, progress func(current, total int)) (
 		return nil, err
 	}
 
-	if progress != nil {
-		progress(2, 2+limit)
-	}
-
 	// If we don't have enough videos, try one more strategy
 	if len(videos) < limit {
-		altUrl := "https://www.youtube.com/results?search_query=" + urlQueryEscape(query) + "&sp=EgIQAQ%25253D%25253D&hl=en&gl=US"
-		altResp, err := httpClient.Get(altUrl)
+		altUrl := "https://www.youtube.com/results?search_query=" + urlQueryEscape(query) + "&sp=EgIQAQ%25253D%25253D&" + locale.query()
+		altBody, err := get(ctx, altUrl, network.Timeout, nil)
 		if err == nil {
-			defer altResp.Body.Close()
-			altBody, err := io.ReadAll(altResp.Body)
+			altVideos, err := extractVideosFromJSON(altBody, limit-len(videos))
 			if err == nil {
-				altVideos, err := extractVideosFromJSON(altBody, limit-len(videos))
-				if err == nil {
-					// Merge videos avoiding duplicates
-					existingURLs := make(map[string]bool)
-					for _, v := range videos {
-						existingURLs[v.URL] = true
-					}
+				// Merge videos avoiding duplicates
+				existingURLs := make(map[string]bool)
+				for _, v := range videos {
+					existingURLs[v.URL] = true
+				}
 
-					for _, v := range altVideos {
-						if !existingURLs[v.URL] && len(videos) < limit {
-							videos = append(videos, v)
-							existingURLs[v.URL] = true
-						}
+				for _, v := range altVideos {
+					if !existingURLs[v.URL] && len(videos) < limit {
+						videos = append(videos, v)
+						existingURLs[v.URL] = true
 					}
 				}
 			}
@@ -187,129 +275,84 @@ func SearchYouTube(query string, limit int, progress func(current, total int)) (
 		videos = videos[:limit]
 	}
 
-	if progress != nil {
-		progress(2, 2+limit)
-	}
+	return videos, nil
+}
 
-	// Load all thumbnails concurrently for faster loading
+// prefetchThumbnails caches the thumbnail of every video with a fixed
+// number of workers, which thumbSlots bounds across searches as well. They
+// stop taking new thumbnails once ctx is canceled.
+func prefetchThumbnails(ctx context.Context, videos []types.Video) {
+	urls := make(chan string)
 	var wg sync.WaitGroup
-	var mu sync.Mutex
-	done := 0
-
-	for i := 0; i < len(videos); i++ {
+	for range min(network.ThumbnailConcurrency, len(videos)) {
 		wg.Add(1)
-		go func(i int) {
+		go func() {
 			defer wg.Done()
-			thumbPath := cacheThumbnailOptimized(videos[i].Thumbnail)
-			if thumbPath == "" && videos[i].Thumbnail != "" {
-				thumbPath = tryFallbackThumbnails(videos[i].Thumbnail)
-			}
-			videos[i].ThumbnailPath = thumbPath
-
-			mu.Lock()
-			done++
-			if progress != nil {
-				progress(2+done, 2+limit)
+			for url := range urls {
+				withThumbSlot(ctx, func() {
+					FetchThumbnail(ctx, url)
+				})
 			}
-			mu.Unlock()
-		}(i)
-	}
-
-	wg.Wait()
-	CleanupHTTPConnections()
-
-	return videos, nil
-}
-
-// Optimized fallback thumbnail function
-func tryFallbackThumbnails(originalURL string) string {
-	fallbackURLs := []string{
-		strings.ReplaceAll(originalURL, "default", "hqdefault"),
-		strings.ReplaceAll(originalURL, "default", "mqdefault"),
-		strings.ReplaceAll(originalURL, "default", "sddefault"),
-		strings.ReplaceAll(originalURL, "default", "maxresdefault"),
+		}()
 	}
-
-	for _, fallbackURL := range fallbackURLs {
-		if fallbackURL != originalURL {
-			thumbPath := cacheThumbnailOptimized(fallbackURL)
-			if thumbPath != "" {
-				return thumbPath
-			}
+feed:
+	for _, v := range videos {
+		if v.Thumbnail == "" {
+			continue
+		}
+		select {
+		case urls <- v.Thumbnail:
+		case <-ctx.Done():
+			break feed
 		}
 	}
-	return ""
+	close(urls)
+	wg.Wait()
+	thumbCache.Trim()
 }
 
-func cacheThumbnailOptimized(url string) string {
+// FetchThumbnail returns the cached thumbnail for url, downloading it first
+// when needed. Not every video has every size, so when url fails the other
+// sizes are tried and stored under url. It returns "" when no image could be
+// had.
+func FetchThumbnail(ctx context.Context, url string) string {
 	if url == "" {
 		return ""
 	}
-	hash := fmt.Sprintf("%x", md5.Sum([]byte(url)))
-	cacheDir := "/tmp/gophertube-thumbs"
-	os.MkdirAll(cacheDir, 0o755)
-	thumbPath := filepath.Join(cacheDir, hash+".jpg")
-
-	if _, err := os.Stat(thumbPath); err == nil {
+	if thumbPath, ok := thumbCache.Lookup(url); ok {
 		return thumbPath
 	}
+	if offline {
+		return ""
+	}
 
-	// Try multiple times with different timeouts
-	for attempt := 0; attempt < 3; attempt++ {
-		timeout := time.Duration(2+attempt) * time.Second
-		client := &http.Client{
-			Timeout: timeout,
-			Transport: &http.Transport{
-				MaxIdleConns:        10,
-				MaxIdleConnsPerHost: 5,
-				IdleConnTimeout:     30 * time.Second,
-				DisableCompression:  false,
-			},
-		}
-
-		req, err := http.NewRequest("GET", url, nil)
-		if err != nil {
-			continue
+	candidates := []string{url}
+	for _, size := range []string{"hqdefault", "mqdefault", "sddefault", "maxresdefault"} {
+		if alt := strings.ReplaceAll(url, "default", size); alt != url {
+			candidates = append(candidates, alt)
 		}
-
-		req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36")
-		req.Header.Set("Accept", "image/webp,image/apng,image/*,*/*;q=0.8")
-		req.Header.Set("Accept-Encoding", "gzip, deflate")
-		req.Header.Set("Connection", "keep-alive")
-		req.Header.Set("Cache-Control", "no-cache")
-		req.Header.Set("Referer", "https://www.youtube.com/")
-
-		resp, err := client.Do(req)
+	}
+	for _, c := range candidates {
+		data, err := get(ctx, c, network.ThumbnailTimeout, thumbHeader)
 		if err != nil {
+			if ctx.Err() != nil {
+				return ""
+			}
 			continue
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != 200 {
-			continue
-		}
-
-		data, err := io.ReadAll(io.LimitReader(resp.Body, 512*1024))
-		if err != nil || len(data) == 0 || isHTML(data) {
-			continue
-		}
-
-		tempPath := thumbPath + ".tmp"
-		if err := ioutil.WriteFile(tempPath, data, 0o644); err != nil {
-			continue
-		}
-
-		if err := os.Rename(tempPath, thumbPath); err != nil {
-			os.Remove(tempPath)
-			continue
+		if thumbPath, err := thumbCache.Store(url, data); err == nil {
+			return thumbPath
 		}
-
-		return thumbPath
 	}
-
 	return ""
 }
 
+// thumbHeader makes thumbnail requests look like those of the web player.
+var thumbHeader = http.Header{
+	"Accept":  {"image/webp,image/apng,image/*,*/*;q=0.8"},
+	"Referer": {"https://www.youtube.com/"},
+}
+
 // CleanupHTTPConnections closes idle connections to prevent memory leaks
 func CleanupHTTPConnections() {
 	if transport, ok := httpClient.Transport.(*http.Transport); ok {
@@ -330,6 +373,14 @@ func parseVideoRenderer(vr interface{}) types.Video {
 	videoId := safeJQString(m, "videoId")
 	url := "https://www.youtube.com/watch?v=" + videoId
 	channel := safeJQText(m, "longBylineText", "runs", 0, "text")
+	channelID := ""
+	if byline, ok := m["longBylineText"].(map[string]interface{}); ok {
+		if runs, ok := byline["runs"].([]interface{}); ok && len(runs) > 0 {
+			if run, ok := runs[0].(map[string]interface{}); ok {
+				channelID = safeJQString(run, "navigationEndpoint", "browseEndpoint", "browseId")
+			}
+		}
+	}
 	duration := safeJQString(m, "lengthText", "simpleText")
 	views := safeJQString(m, "viewCountText", "simpleText")
 	thumb := ""
@@ -362,13 +413,19 @@ func parseVideoRenderer(vr interface{}) types.Video {
 	published := safeJQString(m, "publishedTimeText", "simpleText")
 
 	return types.Video{
-		Title:     title,
-		URL:       url,
-		Author:    channel,
-		Duration:  duration,
-		Views:     views,
-		Thumbnail: thumb,
-		Published: published,
+		Title:       title,
+		URL:         url,
+		Author:      channel,
+		ChannelID:   channelID,
+		Duration:    duration,
+		Views:       views,
+		Thumbnail:   thumb,
+		Published:   published,
+		Length:      ParseDuration(duration),
+		ViewCount:   ParseViewCount(views, locale.base()),
+		Viewers:     ParseViewers(views, locale.base()),
+		PublishedAt: ParsePublished(published, time.Now()),
+		Live:        duration == "" && videoId != "",
 	}
 }
 
@@ -402,7 +459,3 @@ func safeJQText(m map[string]interface{}, k1, k2 string, idx int, k3 string) str
 	}
 	return ""
 }
-
-func isHTML(data []byte) bool {
-	return bytes.HasPrefix(data, []byte("<!DOCTYPE html")) || bytes.HasPrefix(data, []byte("<html"))
-}
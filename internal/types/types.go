@@ -1,9 +1,12 @@
 package types
 
+import "time"
+
 // Video represents a YouTube video with all its metadata
 type Video struct {
 	Title         string
 	Author        string
+	ChannelID     string
 	Duration      string
 	Views         string
 	URL           string
@@ -11,4 +14,11 @@ type Video struct {
 	ThumbnailPath string // local path for preview
 	Description   string
 	Published     string // relative published/upload date
+
+	// Parsed forms of the display strings above, zero when unknown.
+	Length      time.Duration
+	ViewCount   int64
+	Viewers     int64     // people watching a live stream right now
+	PublishedAt time.Time // approximate, relative dates are coarse
+	Live        bool      // live stream or upcoming premiere, no fixed length
 }
@@ -4,13 +4,23 @@ import (
 	"context"
 	"fmt"
 	"gophertube/internal/app"
+	"gophertube/internal/services"
 	"os"
 )
 
 func main() {
+	// Before the flags read the config file from its new place
+	moved, err := services.MigrateLegacyDirs()
+	for _, m := range moved {
+		fmt.Fprintf(os.Stderr, "Moved %s to %s\n", m.From, m.To)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, app.FormatError(err))
+	}
+
 	gophertube := app.New()
 	if err := gophertube.Run(context.Background(), os.Args); err != nil {
-		fmt.Fprintf(os.Stderr, "\n\033[1;33m%v\033[0m\n", err)
+		fmt.Fprintf(os.Stderr, "\n%s\n", app.FormatError(err))
 		os.Exit(1)
 	}
 }